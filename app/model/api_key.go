@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// APIKey dipakai script/integrasi untuk mengakses API tanpa login sebagai user.
+// Key mentah hanya ditampilkan sekali saat dibuat, yang disimpan hanya hash-nya.
+// Hak akses diambil dari RoleID (mengikuti perubahan role) atau dari daftar Permissions tetap.
+type APIKey struct {
+	ID          bson.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Name        string         `bson:"name" json:"name"`
+	Prefix      string         `bson:"prefix" json:"prefix"` // beberapa karakter awal key untuk identifikasi
+	KeyHash     string         `bson:"key_hash" json:"-"`
+	RoleID      *bson.ObjectID `bson:"role_id,omitempty" json:"role_id,omitempty"`
+	Permissions []string       `bson:"permissions,omitempty" json:"permissions,omitempty"`
+	CreatedBy   bson.ObjectID  `bson:"created_by" json:"created_by"`
+	CreatedAt   time.Time      `bson:"created_at" json:"created_at"`
+	ExpiresAt   *time.Time     `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+	LastUsedAt  *time.Time     `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+	RevokedAt   *time.Time     `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name        string         `json:"name"`
+	RoleID      *bson.ObjectID `json:"role_id"`
+	Permissions []string       `json:"permissions"`
+	ExpiresAt   *time.Time     `json:"expires_at"` // opsional, kosong berarti tidak kedaluwarsa
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Invitation memberi izin mendaftar dengan role yang sudah disetujui admin.
+// Token hanya disimpan dalam bentuk hash dan hanya bisa dipakai sekali.
+type Invitation struct {
+	ID             bson.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	Email          string         `bson:"email" json:"email"`
+	RoleID         bson.ObjectID  `bson:"role_id" json:"role_id"`
+	AlumniID       *bson.ObjectID `bson:"alumni_id,omitempty" json:"alumni_id,omitempty"` // akun yang dibuat otomatis ditautkan ke alumni ini
+	TokenHash      string         `bson:"token_hash" json:"-"`
+	ExpiresAt      time.Time      `bson:"expires_at" json:"expires_at"`
+	CreatedBy      bson.ObjectID  `bson:"created_by" json:"created_by"`
+	CreatedAt      time.Time      `bson:"created_at" json:"created_at"`
+	AcceptedAt     *time.Time     `bson:"accepted_at,omitempty" json:"accepted_at,omitempty"`
+	AcceptedUserID *bson.ObjectID `bson:"accepted_user_id,omitempty" json:"accepted_user_id,omitempty"`
+	LastSentAt     time.Time      `bson:"last_sent_at" json:"last_sent_at"`
+	SendCount      int            `bson:"send_count" json:"send_count"`
+
+	Status string `bson:"-" json:"status"` // pending, accepted, atau expired (dihitung saat dibaca)
+}
+
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationExpired  = "expired"
+)
+
+// ComputeStatus mengisi Status berdasarkan accepted_at dan expires_at
+func (inv *Invitation) ComputeStatus(now time.Time) {
+	switch {
+	case inv.AcceptedAt != nil:
+		inv.Status = InvitationAccepted
+	case !inv.ExpiresAt.After(now):
+		inv.Status = InvitationExpired
+	default:
+		inv.Status = InvitationPending
+	}
+}
+
+type CreateInvitationRequest struct {
+	Email  string        `json:"email"`
+	RoleID bson.ObjectID `json:"role_id"`
+}
+
+type InviteAlumniRequest struct {
+	AlumniIDs []bson.ObjectID `json:"alumni_ids"`
+	RoleID    bson.ObjectID   `json:"role_id"`
+}
+
+// InviteAlumniResult adalah hasil undangan per alumni pada undangan massal
+type InviteAlumniResult struct {
+	AlumniID     bson.ObjectID  `json:"alumni_id"`
+	Email        string         `json:"email,omitempty"`
+	Success      bool           `json:"success"`
+	Message      string         `json:"message"`
+	InvitationID *bson.ObjectID `json:"invitation_id,omitempty"`
+}
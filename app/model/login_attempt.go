@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// LoginAttempt menghitung kegagalan login per key ("email:<email>" atau "ip:<ip>").
+// Dokumen dihapus otomatis oleh TTL index setelah ExpiresAt.
+type LoginAttempt struct {
+	Key           string     `bson:"_id" json:"key"`
+	Failures      int        `bson:"failures" json:"failures"`
+	LastFailureAt time.Time  `bson:"last_failure_at" json:"last_failure_at"`
+	LockedUntil   *time.Time `bson:"locked_until,omitempty" json:"locked_until,omitempty"`
+	ExpiresAt     time.Time  `bson:"expires_at" json:"expires_at"`
+}
+
+// LockoutEvent dicatat setiap kali sebuah key terkunci karena terlalu banyak gagal login
+type LockoutEvent struct {
+	ID          bson.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Key         string        `bson:"key" json:"key"`
+	Email       string        `bson:"email" json:"email"`
+	IP          string        `bson:"ip" json:"ip"`
+	UserAgent   string        `bson:"user_agent" json:"user_agent"`
+	Failures    int           `bson:"failures" json:"failures"`
+	LockedUntil time.Time     `bson:"locked_until" json:"locked_until"`
+	CreatedAt   time.Time     `bson:"created_at" json:"created_at"`
+}
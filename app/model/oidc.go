@@ -0,0 +1,15 @@
+package model
+
+import (
+	"time"
+)
+
+// OIDCLoginState menyimpan state, nonce, dan code_verifier PKCE selama user berada di halaman login IdP.
+// Dokumen dipakai sekali saat callback lalu dihapus (atau dihapus TTL index setelah ExpiresAt).
+type OIDCLoginState struct {
+	StateHash    string    `bson:"_id"`
+	Nonce        string    `bson:"nonce"`
+	CodeVerifier string    `bson:"code_verifier"`
+	ExpiresAt    time.Time `bson:"expires_at"`
+	CreatedAt    time.Time `bson:"created_at"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// PasswordResetToken disimpan dalam bentuk hash, hanya bisa dipakai sekali dan memiliki masa berlaku
+type PasswordResetToken struct {
+	ID        bson.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID    bson.ObjectID `bson:"user_id" json:"user_id"`
+	TokenHash string        `bson:"token_hash" json:"-"`
+	ExpiresAt time.Time     `bson:"expires_at" json:"expires_at"`
+	CreatedAt time.Time     `bson:"created_at" json:"created_at"`
+	UsedAt    *time.Time    `bson:"used_at,omitempty" json:"used_at,omitempty"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
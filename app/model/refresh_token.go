@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// RefreshToken disimpan dalam bentuk hash. Setiap rotasi membuat dokumen baru
+// dengan FamilyID yang sama sehingga seluruh rantai bisa dicabut sekaligus.
+type RefreshToken struct {
+	ID        bson.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID    bson.ObjectID `bson:"user_id" json:"user_id"`
+	FamilyID  bson.ObjectID `bson:"family_id" json:"family_id"`
+	TokenHash string        `bson:"token_hash" json:"-"`
+	AMR       []string      `bson:"amr,omitempty" json:"amr,omitempty"` // metode autentikasi saat login, diwariskan saat rotasi
+	ExpiresAt time.Time     `bson:"expires_at" json:"expires_at"`
+	CreatedAt time.Time     `bson:"created_at" json:"created_at"`
+	UsedAt    *time.Time    `bson:"used_at,omitempty" json:"used_at,omitempty"`
+	RevokedAt *time.Time    `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
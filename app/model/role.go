@@ -0,0 +1,129 @@
+package model
+
+import (
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Permission berformat "<resource>:<aksi>". "*" berarti semua permission dan
+// "<resource>:*" berarti semua aksi pada resource tersebut.
+const (
+	PermissionAll = "*"
+
+	PermUsersRead   = "users:read"
+	PermUsersWrite  = "users:write"
+	PermUsersDelete = "users:delete"
+	// PermUsersImpersonate mengizinkan login sebagai user lain untuk keperluan support
+	PermUsersImpersonate = "users:impersonate"
+
+	PermAlumniRead   = "alumni:read"
+	PermAlumniWrite  = "alumni:write"
+	PermAlumniDelete = "alumni:delete"
+
+	PermPekerjaanRead         = "pekerjaan:read"
+	PermPekerjaanWrite        = "pekerjaan:write"
+	PermPekerjaanDelete       = "pekerjaan:delete"
+	PermPekerjaanTrashRead    = "pekerjaan:trash:read"
+	PermPekerjaanTrashRestore = "pekerjaan:trash:restore"
+	PermPekerjaanTrashPurge   = "pekerjaan:trash:purge"
+
+	PermFilesRead   = "files:read"
+	PermFilesUpload = "files:upload"
+	PermFilesDelete = "files:delete"
+
+	PermSecurityRead = "security:read"
+
+	PermRolesManage = "roles:manage"
+
+	PermAPIKeysManage = "api_keys:manage"
+)
+
+// AllPermissions adalah daftar permission yang dikenali aplikasi
+var AllPermissions = []string{
+	PermUsersRead, PermUsersWrite, PermUsersDelete, PermUsersImpersonate,
+	PermAlumniRead, PermAlumniWrite, PermAlumniDelete,
+	PermPekerjaanRead, PermPekerjaanWrite, PermPekerjaanDelete,
+	PermPekerjaanTrashRead, PermPekerjaanTrashRestore, PermPekerjaanTrashPurge,
+	PermFilesRead, PermFilesUpload, PermFilesDelete,
+	PermSecurityRead,
+	PermRolesManage,
+	PermAPIKeysManage,
+}
+
+// IsValidPermission mengecek permission yang dikenal, termasuk "*" dan wildcard "<resource>:*"
+func IsValidPermission(permission string) bool {
+	if permission == PermissionAll {
+		return true
+	}
+	for _, p := range AllPermissions {
+		if p == permission {
+			return true
+		}
+		if strings.HasSuffix(permission, ":*") && strings.HasPrefix(p, strings.TrimSuffix(permission, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+type Role struct {
+	ID          bson.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Role        string        `bson:"role" json:"role"`
+	Permissions []string      `bson:"permissions" json:"permissions"`
+}
+
+// HasPermission mengecek apakah role memiliki permission (termasuk lewat wildcard)
+func (r *Role) HasPermission(permission string) bool {
+	for _, p := range r.Permissions {
+		if p == PermissionAll || p == permission {
+			return true
+		}
+		if strings.HasSuffix(p, ":*") && strings.HasPrefix(permission, strings.TrimSuffix(p, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAllPermissions mengecek apakah role memiliki permission "*" (super admin)
+func (r *Role) HasAllPermissions() bool {
+	for _, p := range r.Permissions {
+		if p == PermissionAll {
+			return true
+		}
+	}
+	return false
+}
+
+type CreateRoleRequest struct {
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateRoleRequest struct {
+	Role        string    `json:"role"`
+	Permissions *[]string `json:"permissions"`
+}
+
+type ReassignRoleRequest struct {
+	ToRoleID bson.ObjectID `json:"to_role_id"`
+}
+
+// DefaultRoles adalah role bawaan yang dibuat saat startup jika belum ada
+var DefaultRoles = []Role{
+	{Role: "admin", Permissions: []string{PermissionAll}},
+	{Role: "staff", Permissions: []string{
+		PermAlumniRead, PermAlumniWrite,
+		PermPekerjaanRead, PermPekerjaanWrite, PermPekerjaanDelete, PermPekerjaanTrashRead, PermPekerjaanTrashRestore,
+		PermFilesRead, PermFilesUpload,
+	}},
+	{Role: "user", Permissions: DefaultUserPermissions},
+}
+
+// DefaultUserPermissions diberikan ke role lama yang belum punya daftar permission
+var DefaultUserPermissions = []string{
+	PermAlumniRead,
+	PermPekerjaanRead, PermPekerjaanTrashRead,
+	PermFilesRead, PermFilesUpload,
+}
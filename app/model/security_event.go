@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	SecurityEventLoginSuccess    = "login_success"
+	SecurityEventLoginFailure    = "login_failure"
+	SecurityEventPasswordChanged = "password_changed"
+	SecurityEventRoleChanged     = "role_changed"
+	SecurityEventTokensRevoked   = "tokens_revoked"
+
+	SecurityEventImpersonationStarted = "impersonation_started"
+	SecurityEventImpersonatedRequest  = "impersonated_request"
+)
+
+// SecurityEvent mencatat kejadian keamanan akun beserta asal request-nya.
+// UserID kosong untuk kejadian yang tidak bisa dikaitkan ke user, mis. login dengan email tidak terdaftar.
+type SecurityEvent struct {
+	ID        bson.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID    *bson.ObjectID `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	Email     string         `bson:"email,omitempty" json:"email,omitempty"`
+	Type      string         `bson:"type" json:"type"`
+	IP        string         `bson:"ip" json:"ip"`
+	UserAgent string         `bson:"user_agent" json:"user_agent"`
+	ActorID   *bson.ObjectID `bson:"actor_id,omitempty" json:"actor_id,omitempty"` // admin yang melakukan aksi, kosong jika user sendiri
+	Details   bson.M         `bson:"details,omitempty" json:"details,omitempty"`
+	CreatedAt time.Time      `bson:"created_at" json:"created_at"`
+}
+
+// SecurityEventFilter adalah filter query security event, field kosong berarti tidak difilter
+type SecurityEventFilter struct {
+	UserID *bson.ObjectID
+	Type   string
+	From   *time.Time
+	To     *time.Time
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Session mewakili satu login (satu perangkat/browser). ID session sama dengan
+// family_id refresh token dan dibawa access token lewat claim "sid".
+type Session struct {
+	ID         bson.ObjectID `bson:"_id" json:"id"`
+	UserID     bson.ObjectID `bson:"user_id" json:"user_id"`
+	UserAgent  string        `bson:"user_agent" json:"user_agent"`
+	IP         string        `bson:"ip" json:"ip"`
+	AMR        []string      `bson:"amr,omitempty" json:"amr,omitempty"`
+	CreatedAt  time.Time     `bson:"created_at" json:"created_at"`
+	LastSeenAt time.Time     `bson:"last_seen_at" json:"last_seen_at"`
+	ExpiresAt  time.Time     `bson:"expires_at" json:"expires_at"` // ikut diperpanjang setiap refresh token dirotasi
+	RevokedAt  *time.Time    `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+
+	Current bool `bson:"-" json:"current"` // true untuk session yang sedang dipakai request ini
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// RevokedToken mencatat access token (berdasarkan jti) yang dicabut sebelum kedaluwarsa.
+// Dokumen dihapus otomatis oleh TTL index setelah ExpiresAt.
+type RevokedToken struct {
+	JTI       string        `bson:"_id" json:"jti"`
+	UserID    bson.ObjectID `bson:"user_id" json:"user_id"`
+	RevokedAt time.Time     `bson:"revoked_at" json:"revoked_at"`
+	ExpiresAt time.Time     `bson:"expires_at" json:"expires_at"`
+}
+
+// UserTokenRevocation mencabut semua access token user yang diterbitkan sebelum RevokedBefore
+type UserTokenRevocation struct {
+	UserID        bson.ObjectID `bson:"_id" json:"user_id"`
+	RevokedBefore time.Time     `bson:"revoked_before" json:"revoked_before"`
+	ExpiresAt     time.Time     `bson:"expires_at" json:"expires_at"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
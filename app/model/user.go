@@ -13,11 +13,28 @@ type User struct {
 	AlumniID  *bson.ObjectID `bson:"alumni_id,omitempty" json:"alumni_id"`
 	RoleID    bson.ObjectID `bson:"role_id" json:"role_id"`
 	CreatedAt time.Time     `bson:"created_at" json:"created_at"`
-}
 
-type Role struct {
-	ID   bson.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	Role string        `bson:"role" json:"role"`
+	EmailVerified      bool       `bson:"email_verified" json:"email_verified"`
+	EmailVerifiedAt    *time.Time `bson:"email_verified_at,omitempty" json:"email_verified_at,omitempty"`
+	VerificationSentAt *time.Time `bson:"verification_sent_at,omitempty" json:"-"`
+	ResetSentAt        *time.Time `bson:"password_reset_sent_at,omitempty" json:"-"`
+
+	TOTPEnabled       bool     `bson:"totp_enabled" json:"totp_enabled"`
+	TOTPSecret        string   `bson:"totp_secret,omitempty" json:"-"`
+	TOTPPendingSecret string   `bson:"totp_pending_secret,omitempty" json:"-"`
+	TOTPLastStep      int64    `bson:"totp_last_step,omitempty" json:"-"`
+	RecoveryCodes     []string `bson:"recovery_codes,omitempty" json:"-"` // hash SHA-256, sekali pakai
+
+	PasswordHistory []string `bson:"password_history,omitempty" json:"-"` // hash password sebelumnya, terbaru di akhir
+
+	// Status akun, kosong dianggap active (user lama)
+	Status          string     `bson:"status,omitempty" json:"status"`
+	SuspendedReason string     `bson:"suspended_reason,omitempty" json:"suspended_reason,omitempty"`
+	SuspendedUntil  *time.Time `bson:"suspended_until,omitempty" json:"suspended_until,omitempty"` // nil berarti sampai diaktifkan kembali
+
+	// Identitas dari IdP OIDC (issuer + subject) yang tertaut ke akun ini
+	OIDCIssuer  string `bson:"oidc_issuer,omitempty" json:"-"`
+	OIDCSubject string `bson:"oidc_subject,omitempty" json:"-"`
 }
 
 type UserResponse struct {
@@ -27,13 +44,25 @@ type UserResponse struct {
 	AlumniID  *bson.ObjectID `bson:"alumni_id,omitempty" json:"alumni_id"`
 	RoleID    bson.ObjectID  `bson:"role_id" json:"role_id"`
 	CreatedAt time.Time      `bson:"created_at" json:"created_at"`
+
+	EmailVerified bool `bson:"email_verified" json:"email_verified"`
+	TOTPEnabled   bool `bson:"totp_enabled" json:"totp_enabled"`
+
+	Status          string     `bson:"status" json:"status"`
+	SuspendedReason string     `bson:"suspended_reason,omitempty" json:"suspended_reason,omitempty"`
+	SuspendedUntil  *time.Time `bson:"suspended_until,omitempty" json:"suspended_until,omitempty"`
 }
 
 type RegisterRequest struct {
 	Username  string        `bson:"username" json:"username"`
 	Email     string        `bson:"email" json:"email"`
 	Password  string        `bson:"password" json:"password"`
-	RoleID    bson.ObjectID `bson:"role_id" json:"role_id"`
+	RoleID    bson.ObjectID `bson:"role_id" json:"role_id"` // tidak boleh diisi client, role ditentukan server
+
+	// InviteToken opsional, berisi token undangan yang membawa role yang sudah disetujui admin
+	InviteToken string `bson:"-" json:"invite_token"`
+	// AlumniID diisi server dari undangan, tidak dibaca dari body request
+	AlumniID *bson.ObjectID `bson:"-" json:"-"`
 }
 
 type CreateUserRequest struct {
@@ -42,6 +71,9 @@ type CreateUserRequest struct {
 	Password  string         `bson:"password" json:"password"`
 	RoleID    bson.ObjectID  `bson:"role_id" json:"role_id"`
 	AlumniID  *bson.ObjectID `bson:"alumni_id,omitempty" json:"alumni_id"`
+
+	// SkipVerification dipakai admin untuk membuat akun yang langsung terverifikasi
+	SkipVerification bool `bson:"-" json:"skip_verification"`
 }
 
 type UpdateUserRequest struct {
@@ -52,8 +84,45 @@ type UpdateUserRequest struct {
 	AlumniID  *bson.ObjectID `bson:"alumni_id,omitempty" json:"alumni_id,omitempty"`
 }
 
+type ResendVerificationRequest struct {
+	Email string `json:"email"`
+}
+
+// TwoFactorLoginRequest untuk langkah kedua login jika 2FA aktif (isi code atau recovery_code)
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password     string `json:"password"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
 // LoginRequest untuk data input login
 type LoginRequest struct {
 	Email     string `bson:"username" json:"email"`
 	Password  string `bson:"password" json:"password"`
-}
\ No newline at end of file
+}
+// UpdateMeRequest dipakai user untuk mengubah profilnya sendiri (PATCH /api/me)
+type UpdateMeRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// MeResponse adalah profil user yang sedang login beserta data alumni yang tertaut
+type MeResponse struct {
+	User   *UserResponse `json:"user"`
+	Alumni *Alumni       `json:"alumni"`
+}
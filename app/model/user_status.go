@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	UserStatusActive      = "active"
+	UserStatusSuspended   = "suspended"
+	UserStatusDeactivated = "deactivated"
+)
+
+// EffectiveStatus mengembalikan status akun saat `now`. Suspend yang sudah lewat
+// batas waktunya dianggap active kembali.
+func (u *User) EffectiveStatus(now time.Time) string {
+	switch u.Status {
+	case UserStatusDeactivated:
+		return UserStatusDeactivated
+	case UserStatusSuspended:
+		if u.SuspendedUntil == nil || u.SuspendedUntil.After(now) {
+			return UserStatusSuspended
+		}
+	}
+	return UserStatusActive
+}
+
+// UserStatusEvent mencatat setiap perubahan status akun oleh admin
+type UserStatusEvent struct {
+	ID        bson.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID    bson.ObjectID `bson:"user_id" json:"user_id"`
+	Action    string        `bson:"action" json:"action"` // suspend, reactivate, deactivate
+	Reason    string        `bson:"reason,omitempty" json:"reason,omitempty"`
+	Until     *time.Time    `bson:"until,omitempty" json:"until,omitempty"`
+	ActorID   bson.ObjectID `bson:"actor_id" json:"actor_id"`
+	CreatedAt time.Time     `bson:"created_at" json:"created_at"`
+}
+
+type SuspendUserRequest struct {
+	Reason string     `json:"reason"`
+	Until  *time.Time `json:"until"` // opsional, kosong berarti sampai diaktifkan kembali
+}
+
+type UserStatusRequest struct {
+	Reason string `json:"reason"`
+}
@@ -19,9 +19,9 @@ func NewAlumniRepositoryMongo() *AlumniRepositoryMongo {
 }
 
 // CreateAlumni membuat alumni baru dan mengembalikan ID (bson.ObjectID)
-func (r *AlumniRepositoryMongo) CreateAlumni(req model.CreateAlumniRequest) (bson.ObjectID, error) {
+func (r *AlumniRepositoryMongo) CreateAlumni(ctx context.Context, req model.CreateAlumniRequest) (bson.ObjectID, error) {
 	collection := database.MongoDB.Collection("alumni")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	id := bson.NewObjectID()
@@ -48,9 +48,9 @@ func (r *AlumniRepositoryMongo) CreateAlumni(req model.CreateAlumniRequest) (bso
 }
 
 // GetAllAlumni mengambil semua alumni
-func (r *AlumniRepositoryMongo) GetAllAlumni() ([]model.Alumni, error) {
+func (r *AlumniRepositoryMongo) GetAllAlumni(ctx context.Context) ([]model.Alumni, error) {
 	collection := database.MongoDB.Collection("alumni")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	cursor, err := collection.Find(ctx, bson.M{})
@@ -68,9 +68,9 @@ func (r *AlumniRepositoryMongo) GetAllAlumni() ([]model.Alumni, error) {
 }
 
 // GetAllAlumniWithPagination untuk mendukung pagination
-func (r *AlumniRepositoryMongo) GetAllAlumniWithPagination(page, limit int64) ([]model.Alumni, int64, error) {
+func (r *AlumniRepositoryMongo) GetAllAlumniWithPagination(ctx context.Context, page, limit int64) ([]model.Alumni, int64, error) {
 	collection := database.MongoDB.Collection("alumni")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	// Hitung total dokumen
@@ -99,9 +99,9 @@ func (r *AlumniRepositoryMongo) GetAllAlumniWithPagination(page, limit int64) ([
 }
 
 // GetAlumniByID mengambil alumni berdasarkan ID (bson.ObjectID)
-func (r *AlumniRepositoryMongo) GetAlumniByID(id bson.ObjectID) (*model.Alumni, error) {
+func (r *AlumniRepositoryMongo) GetAlumniByID(ctx context.Context, id bson.ObjectID) (*model.Alumni, error) {
 	collection := database.MongoDB.Collection("alumni")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	var alumni model.Alumni
@@ -113,9 +113,9 @@ func (r *AlumniRepositoryMongo) GetAlumniByID(id bson.ObjectID) (*model.Alumni,
 }
 
 // UpdateAlumni mengupdate data alumni berdasarkan ID (bson.ObjectID)
-func (r *AlumniRepositoryMongo) UpdateAlumni(id bson.ObjectID, req model.UpdateAlumniRequest) error {
+func (r *AlumniRepositoryMongo) UpdateAlumni(ctx context.Context, id bson.ObjectID, req model.UpdateAlumniRequest) error {
 	collection := database.MongoDB.Collection("alumni")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	updateFields := bson.M{}
@@ -163,9 +163,9 @@ func (r *AlumniRepositoryMongo) UpdateAlumni(id bson.ObjectID, req model.UpdateA
 }
 
 // DeleteAlumni menghapus alumni berdasarkan ID (bson.ObjectID)
-func (r *AlumniRepositoryMongo) DeleteAlumni(id bson.ObjectID) error {
+func (r *AlumniRepositoryMongo) DeleteAlumni(ctx context.Context, id bson.ObjectID) error {
 	collection := database.MongoDB.Collection("alumni")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	res, err := collection.DeleteOne(ctx, bson.M{"_id": id})
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"hello-fiber/app/model"
+	"hello-fiber/database"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var ErrAPIKeyInvalid = errors.New("api key tidak valid, dicabut, atau kedaluwarsa")
+
+type APIKeyRepositoryMongo struct{}
+
+func NewAPIKeyRepositoryMongo() *APIKeyRepositoryMongo {
+	return &APIKeyRepositoryMongo{}
+}
+
+// CreateAPIKey menyimpan api key baru (hanya hash-nya)
+func (r *APIKeyRepositoryMongo) CreateAPIKey(ctx context.Context, key *model.APIKey) error {
+	collection := database.MongoDB.Collection("api_keys")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if key.ID.IsZero() {
+		key.ID = bson.NewObjectID()
+	}
+	key.CreatedAt = time.Now()
+
+	_, err := collection.InsertOne(ctx, key)
+	return err
+}
+
+// GetActiveAPIKeyByHash mengambil api key yang belum dicabut dan belum kedaluwarsa
+func (r *APIKeyRepositoryMongo) GetActiveAPIKeyByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	collection := database.MongoDB.Collection("api_keys")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"key_hash":   keyHash,
+		"revoked_at": bson.M{"$exists": false},
+		"$or": bson.A{
+			bson.M{"expires_at": bson.M{"$exists": false}},
+			bson.M{"expires_at": bson.M{"$gt": time.Now()}},
+		},
+	}
+
+	var key model.APIKey
+	if err := collection.FindOne(ctx, filter).Decode(&key); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrAPIKeyInvalid
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// TouchAPIKey memperbarui last_used_at paling sering sekali per `interval`
+func (r *APIKeyRepositoryMongo) TouchAPIKey(ctx context.Context, id bson.ObjectID, interval time.Duration) error {
+	collection := database.MongoDB.Collection("api_keys")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	_, err := collection.UpdateOne(ctx,
+		bson.M{"_id": id, "$or": bson.A{
+			bson.M{"last_used_at": bson.M{"$exists": false}},
+			bson.M{"last_used_at": bson.M{"$lte": now.Add(-interval)}},
+		}},
+		bson.M{"$set": bson.M{"last_used_at": now}},
+	)
+	return err
+}
+
+// GetAllAPIKeys mengambil semua api key, terbaru lebih dulu
+func (r *APIKeyRepositoryMongo) GetAllAPIKeys(ctx context.Context) ([]model.APIKey, error) {
+	collection := database.MongoDB.Collection("api_keys")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	keys := []model.APIKey{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey mencabut api key
+func (r *APIKeyRepositoryMongo) RevokeAPIKey(ctx context.Context, id bson.ObjectID) error {
+	collection := database.MongoDB.Collection("api_keys")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": id, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("api key tidak ditemukan atau sudah dicabut")
+	}
+	return nil
+}
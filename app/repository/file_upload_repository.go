@@ -12,10 +12,10 @@ import (
 )
 
 type FileUploadRepository interface {
-	Create(file *model.FileUpload) error
-	FindAll() ([]model.FileUpload, error)
-	FindByID(id bson.ObjectID) (*model.FileUpload, error)
-	Delete(id bson.ObjectID) error
+	Create(ctx context.Context, file *model.FileUpload) error
+	FindAll(ctx context.Context) ([]model.FileUpload, error)
+	FindByID(ctx context.Context, id bson.ObjectID) (*model.FileUpload, error)
+	Delete(ctx context.Context, id bson.ObjectID) error
 }
 
 type fileUploadRepository struct {
@@ -28,8 +28,8 @@ func NewFileUploadRepository() FileUploadRepository {
 	}
 }
 
-func (r *fileUploadRepository) Create(file *model.FileUpload) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *fileUploadRepository) Create(ctx context.Context, file *model.FileUpload) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	file.UploadedAt = time.Now()
@@ -41,8 +41,8 @@ func (r *fileUploadRepository) Create(file *model.FileUpload) error {
 	return err
 }
 
-func (r *fileUploadRepository) FindAll() ([]model.FileUpload, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *fileUploadRepository) FindAll(ctx context.Context) ([]model.FileUpload, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	var files []model.FileUpload
@@ -59,8 +59,8 @@ func (r *fileUploadRepository) FindAll() ([]model.FileUpload, error) {
 	return files, nil
 }
 
-func (r *fileUploadRepository) FindByID(id bson.ObjectID) (*model.FileUpload, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *fileUploadRepository) FindByID(ctx context.Context, id bson.ObjectID) (*model.FileUpload, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	var file model.FileUpload
@@ -72,8 +72,8 @@ func (r *fileUploadRepository) FindByID(id bson.ObjectID) (*model.FileUpload, er
 	return &file, nil
 }
 
-func (r *fileUploadRepository) Delete(id bson.ObjectID) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *fileUploadRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"hello-fiber/database"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// EnsureIndexes membuat index yang dibutuhkan repository (idempotent, aman dipanggil setiap startup)
+func EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	indexes := map[string][]mongo.IndexModel{
+		"refresh_tokens": {
+			{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "family_id", Value: 1}}},
+			{Keys: bson.D{{Key: "user_id", Value: 1}}},
+			// TTL: dokumen dihapus otomatis setelah expires_at lewat
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		"revoked_tokens": {
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		"password_reset_tokens": {
+			{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "user_id", Value: 1}}},
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		"login_attempts": {
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		"lockout_events": {
+			{Keys: bson.D{{Key: "created_at", Value: -1}}},
+		},
+		"security_events": {
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+			{Keys: bson.D{{Key: "type", Value: 1}, {Key: "created_at", Value: -1}}},
+			{Keys: bson.D{{Key: "created_at", Value: -1}}},
+		},
+		"invitations": {
+			{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "email", Value: 1}}},
+		},
+		"api_keys": {
+			{Keys: bson.D{{Key: "key_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"oidc_states": {
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		"users": {
+			{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "username", Value: 1}}, Options: options.Index().SetUnique(true)},
+			// Satu identitas IdP hanya boleh tertaut ke satu user
+			{Keys: bson.D{{Key: "oidc_issuer", Value: 1}, {Key: "oidc_subject", Value: 1}}, Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"oidc_subject": bson.M{"$exists": true}})},
+		},
+		"user_status_events": {
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		},
+		"sessions": {
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "last_seen_at", Value: -1}}},
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		"user_token_revocations": {
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+	}
+
+	for name, models := range indexes {
+		if _, err := database.MongoDB.Collection(name).Indexes().CreateMany(ctx, models); err != nil {
+			return err
+		}
+	}
+	return nil
+}
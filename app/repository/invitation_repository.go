@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"hello-fiber/app/model"
+	"hello-fiber/database"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var ErrInvitationInvalid = errors.New("undangan tidak valid, sudah dipakai, atau kedaluwarsa")
+
+type InvitationRepositoryMongo struct{}
+
+func NewInvitationRepositoryMongo() *InvitationRepositoryMongo {
+	return &InvitationRepositoryMongo{}
+}
+
+// CreateInvitation menyimpan undangan baru
+func (r *InvitationRepositoryMongo) CreateInvitation(ctx context.Context, inv *model.Invitation) error {
+	collection := database.MongoDB.Collection("invitations")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if inv.ID.IsZero() {
+		inv.ID = bson.NewObjectID()
+	}
+	inv.Email = strings.ToLower(strings.TrimSpace(inv.Email))
+	inv.CreatedAt = time.Now()
+	inv.LastSentAt = inv.CreatedAt
+	inv.SendCount = 1
+
+	_, err := collection.InsertOne(ctx, inv)
+	return err
+}
+
+// AcceptInvitation menandai undangan sebagai diterima secara atomik (hanya undangan aktif untuk email tersebut)
+func (r *InvitationRepositoryMongo) AcceptInvitation(ctx context.Context, tokenHash, email string) (*model.Invitation, error) {
+	collection := database.MongoDB.Collection("invitations")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{
+		"token_hash":  tokenHash,
+		"email":       strings.ToLower(strings.TrimSpace(email)),
+		"accepted_at": bson.M{"$exists": false},
+		"expires_at":  bson.M{"$gt": now},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var inv model.Invitation
+	if err := collection.FindOneAndUpdate(ctx, filter, bson.M{"$set": bson.M{"accepted_at": now}}, opts).Decode(&inv); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrInvitationInvalid
+		}
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// SetAcceptedUser mencatat user yang dibuat dari undangan
+func (r *InvitationRepositoryMongo) SetAcceptedUser(ctx context.Context, id, userID bson.ObjectID) error {
+	collection := database.MongoDB.Collection("invitations")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"accepted_user_id": userID}})
+	return err
+}
+
+// ReleaseInvitation membatalkan status diterima jika pembuatan user gagal, supaya undangan bisa dipakai lagi
+func (r *InvitationRepositoryMongo) ReleaseInvitation(ctx context.Context, id bson.ObjectID) error {
+	collection := database.MongoDB.Collection("invitations")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id, "accepted_user_id": bson.M{"$exists": false}}, bson.M{"$unset": bson.M{"accepted_at": ""}})
+	return err
+}
+
+// GetInvitationByID mengambil undangan berdasarkan ID
+func (r *InvitationRepositoryMongo) GetInvitationByID(ctx context.Context, id bson.ObjectID) (*model.Invitation, error) {
+	collection := database.MongoDB.Collection("invitations")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var inv model.Invitation
+	if err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&inv); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("undangan tidak ditemukan")
+		}
+		return nil, err
+	}
+	inv.ComputeStatus(time.Now())
+	return &inv, nil
+}
+
+// GetInvitations mengambil daftar undangan dengan filter status (pending/accepted/expired, kosong = semua)
+func (r *InvitationRepositoryMongo) GetInvitations(ctx context.Context, status string, page, limit int64) ([]model.Invitation, int64, error) {
+	collection := database.MongoDB.Collection("invitations")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{}
+	switch status {
+	case model.InvitationPending:
+		filter = bson.M{"accepted_at": bson.M{"$exists": false}, "expires_at": bson.M{"$gt": now}}
+	case model.InvitationAccepted:
+		filter = bson.M{"accepted_at": bson.M{"$exists": true}}
+	case model.InvitationExpired:
+		filter = bson.M{"accepted_at": bson.M{"$exists": false}, "expires_at": bson.M{"$lte": now}}
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetSkip((page - 1) * limit).SetLimit(limit)
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var invitations []model.Invitation
+	if err := cursor.All(ctx, &invitations); err != nil {
+		return nil, 0, err
+	}
+	for i := range invitations {
+		invitations[i].ComputeStatus(now)
+	}
+	return invitations, total, nil
+}
+
+// HasPendingInvitation mengecek apakah email masih punya undangan aktif
+func (r *InvitationRepositoryMongo) HasPendingInvitation(ctx context.Context, email string) (bool, error) {
+	collection := database.MongoDB.Collection("invitations")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	count, err := collection.CountDocuments(ctx, bson.M{
+		"email":       strings.ToLower(strings.TrimSpace(email)),
+		"accepted_at": bson.M{"$exists": false},
+		"expires_at":  bson.M{"$gt": time.Now()},
+	})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// RotateInvitationToken mengganti token undangan yang belum diterima (dipakai saat kirim ulang).
+// Token lama otomatis tidak berlaku karena hash-nya diganti.
+func (r *InvitationRepositoryMongo) RotateInvitationToken(ctx context.Context, id bson.ObjectID, tokenHash string, expiresAt time.Time) (*model.Invitation, error) {
+	collection := database.MongoDB.Collection("invitations")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{"token_hash": tokenHash, "expires_at": expiresAt, "last_sent_at": time.Now()},
+		"$inc": bson.M{"send_count": 1},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var inv model.Invitation
+	if err := collection.FindOneAndUpdate(ctx, bson.M{"_id": id, "accepted_at": bson.M{"$exists": false}}, update, opts).Decode(&inv); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("undangan tidak ditemukan atau sudah diterima")
+		}
+		return nil, err
+	}
+	inv.ComputeStatus(time.Now())
+	return &inv, nil
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"hello-fiber/app/model"
+	"hello-fiber/database"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type LoginAttemptRepositoryMongo struct{}
+
+func NewLoginAttemptRepositoryMongo() *LoginAttemptRepositoryMongo {
+	return &LoginAttemptRepositoryMongo{}
+}
+
+// GetAttempt mengambil data kegagalan login untuk key tertentu (nil jika belum ada)
+func (r *LoginAttemptRepositoryMongo) GetAttempt(ctx context.Context, key string) (*model.LoginAttempt, error) {
+	collection := database.MongoDB.Collection("login_attempts")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var attempt model.LoginAttempt
+	if err := collection.FindOne(ctx, bson.M{"_id": key}).Decode(&attempt); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// IncrementFailure menambah jumlah kegagalan secara atomik dan memperpanjang masa simpan sampai `window` ke depan
+func (r *LoginAttemptRepositoryMongo) IncrementFailure(ctx context.Context, key string, window time.Duration) (*model.LoginAttempt, error) {
+	collection := database.MongoDB.Collection("login_attempts")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	update := bson.M{
+		"$inc": bson.M{"failures": 1},
+		"$set": bson.M{"last_failure_at": now},
+		"$max": bson.M{"expires_at": now.Add(window)},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var attempt model.LoginAttempt
+	if err := collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, update, opts).Decode(&attempt); err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// Lock mengunci key sampai lockedUntil (dokumen disimpan minimal sampai kunci berakhir)
+func (r *LoginAttemptRepositoryMongo) Lock(ctx context.Context, key string, lockedUntil, expiresAt time.Time) error {
+	collection := database.MongoDB.Collection("login_attempts")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": key}, bson.M{
+		"$set": bson.M{"locked_until": lockedUntil},
+		"$max": bson.M{"expires_at": expiresAt},
+	})
+	return err
+}
+
+// ResetAttempts menghapus catatan kegagalan (setelah login berhasil atau di-unlock admin)
+func (r *LoginAttemptRepositoryMongo) ResetAttempts(ctx context.Context, key string) error {
+	collection := database.MongoDB.Collection("login_attempts")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
+
+// CreateLockoutEvent mencatat kejadian lockout
+func (r *LoginAttemptRepositoryMongo) CreateLockoutEvent(ctx context.Context, event *model.LockoutEvent) error {
+	collection := database.MongoDB.Collection("lockout_events")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if event.ID.IsZero() {
+		event.ID = bson.NewObjectID()
+	}
+	event.CreatedAt = time.Now()
+
+	_, err := collection.InsertOne(ctx, event)
+	return err
+}
+
+// GetLockoutEvents mengambil kejadian lockout terbaru dengan pagination
+func (r *LoginAttemptRepositoryMongo) GetLockoutEvents(ctx context.Context, page, limit int64) ([]model.LockoutEvent, int64, error) {
+	collection := database.MongoDB.Collection("lockout_events")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	total, err := collection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetSkip((page - 1) * limit).SetLimit(limit)
+	cursor, err := collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var events []model.LockoutEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"hello-fiber/app/model"
+	"hello-fiber/database"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// RunMigrations menyesuaikan data lama dengan skema terbaru (idempotent, dijalankan setiap startup)
+func RunMigrations() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// User yang dibuat sebelum ada verifikasi email dianggap sudah terverifikasi
+	if _, err := database.MongoDB.Collection("users").UpdateMany(ctx,
+		bson.M{"email_verified": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"email_verified": true}},
+	); err != nil {
+		return err
+	}
+
+	return seedDefaultRoles(ctx)
+}
+
+// seedDefaultRoles membuat role bawaan yang belum ada dan mengisi permission role lama.
+// Role lama bernama admin/Admin mendapat semua permission, role lain mendapat permission user biasa.
+func seedDefaultRoles(ctx context.Context) error {
+	roles := database.MongoDB.Collection("roles")
+
+	if _, err := roles.UpdateMany(ctx,
+		bson.M{"role": bson.M{"$in": bson.A{"admin", "Admin"}}, "permissions": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"permissions": []string{model.PermissionAll}}},
+	); err != nil {
+		return err
+	}
+	if _, err := roles.UpdateMany(ctx,
+		bson.M{"permissions": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"permissions": model.DefaultUserPermissions}},
+	); err != nil {
+		return err
+	}
+
+	for _, role := range model.DefaultRoles {
+		names := bson.A{role.Role}
+		if role.Role == "admin" {
+			names = append(names, "Admin")
+		}
+		count, err := roles.CountDocuments(ctx, bson.M{"role": bson.M{"$in": names}})
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		role.ID = bson.NewObjectID()
+		if _, err := roles.InsertOne(ctx, role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"hello-fiber/app/model"
+	"hello-fiber/database"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var ErrOIDCStateInvalid = errors.New("state OIDC tidak valid atau kedaluwarsa")
+
+type OIDCStateRepositoryMongo struct{}
+
+func NewOIDCStateRepositoryMongo() *OIDCStateRepositoryMongo {
+	return &OIDCStateRepositoryMongo{}
+}
+
+// CreateState menyimpan state login OIDC yang sedang berjalan
+func (r *OIDCStateRepositoryMongo) CreateState(ctx context.Context, state *model.OIDCLoginState) error {
+	collection := database.MongoDB.Collection("oidc_states")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	state.CreatedAt = time.Now()
+	_, err := collection.InsertOne(ctx, state)
+	return err
+}
+
+// ConsumeState mengambil sekaligus menghapus state secara atomik sehingga callback tidak bisa diulang
+func (r *OIDCStateRepositoryMongo) ConsumeState(ctx context.Context, stateHash string) (*model.OIDCLoginState, error) {
+	collection := database.MongoDB.Collection("oidc_states")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var state model.OIDCLoginState
+	err := collection.FindOneAndDelete(ctx, bson.M{"_id": stateHash, "expires_at": bson.M{"$gt": time.Now()}}).Decode(&state)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrOIDCStateInvalid
+		}
+		return nil, err
+	}
+	return &state, nil
+}
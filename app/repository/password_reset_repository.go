@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"hello-fiber/app/model"
+	"hello-fiber/database"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var ErrResetTokenInvalid = errors.New("token reset password tidak valid atau kedaluwarsa")
+
+type PasswordResetRepositoryMongo struct{}
+
+func NewPasswordResetRepositoryMongo() *PasswordResetRepositoryMongo {
+	return &PasswordResetRepositoryMongo{}
+}
+
+// CreateResetToken menyimpan token baru dan menonaktifkan token lama milik user yang belum dipakai
+func (r *PasswordResetRepositoryMongo) CreateResetToken(ctx context.Context, token *model.PasswordResetToken) error {
+	collection := database.MongoDB.Collection("password_reset_tokens")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	if _, err := collection.UpdateMany(ctx, bson.M{"user_id": token.UserID, "used_at": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"used_at": now}}); err != nil {
+		return err
+	}
+
+	if token.ID.IsZero() {
+		token.ID = bson.NewObjectID()
+	}
+	token.CreatedAt = now
+
+	_, err := collection.InsertOne(ctx, token)
+	return err
+}
+
+// ConsumeResetToken menandai token sebagai terpakai secara atomik dan mengembalikan dokumennya
+func (r *PasswordResetRepositoryMongo) ConsumeResetToken(ctx context.Context, tokenHash string) (*model.PasswordResetToken, error) {
+	collection := database.MongoDB.Collection("password_reset_tokens")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{
+		"token_hash": tokenHash,
+		"used_at":    bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": now},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var token model.PasswordResetToken
+	if err := collection.FindOneAndUpdate(ctx, filter, bson.M{"$set": bson.M{"used_at": now}}, opts).Decode(&token); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrResetTokenInvalid
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// GetActiveResetToken mengambil token reset yang masih berlaku tanpa memakainya
+func (r *PasswordResetRepositoryMongo) GetActiveResetToken(ctx context.Context, tokenHash string) (*model.PasswordResetToken, error) {
+	collection := database.MongoDB.Collection("password_reset_tokens")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var token model.PasswordResetToken
+	filter := bson.M{"token_hash": tokenHash, "used_at": bson.M{"$exists": false}, "expires_at": bson.M{"$gt": time.Now()}}
+	if err := collection.FindOne(ctx, filter).Decode(&token); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrResetTokenInvalid
+		}
+		return nil, err
+	}
+	return &token, nil
+}
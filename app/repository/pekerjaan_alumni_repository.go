@@ -10,9 +10,13 @@ import (
 	"hello-fiber/database"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
+// ErrPekerjaanNotFound dikembalikan jika pekerjaan dengan ID tersebut tidak ada
+var ErrPekerjaanNotFound = errors.New("pekerjaan alumni tidak ditemukan")
+
 type PekerjaanAlumniRepositoryMongo struct{}
 
 func NewPekerjaanAlumniRepositoryMongo() *PekerjaanAlumniRepositoryMongo {
@@ -20,9 +24,9 @@ func NewPekerjaanAlumniRepositoryMongo() *PekerjaanAlumniRepositoryMongo {
 }
 
 // CreatePekerjaanAlumni membuat pekerjaan alumni baru dan mengembalikan ID (bson.ObjectID)
-func (r *PekerjaanAlumniRepositoryMongo) CreatePekerjaanAlumni(req model.CreatePekerjaanAlumniRequest) (bson.ObjectID, error) {
+func (r *PekerjaanAlumniRepositoryMongo) CreatePekerjaanAlumni(ctx context.Context, req model.CreatePekerjaanAlumniRequest) (bson.ObjectID, error) {
 	collection := database.MongoDB.Collection("pekerjaan_alumni")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	if req.AlumniID.IsZero() {
@@ -59,9 +63,9 @@ func (r *PekerjaanAlumniRepositoryMongo) CreatePekerjaanAlumni(req model.CreateP
 
 // repository/pekerjaan_alumni_repository.go
 
-func (r *PekerjaanAlumniRepositoryMongo) GetAllPekerjaanAlumni() ([]model.PekerjaanAlumni, error) {
+func (r *PekerjaanAlumniRepositoryMongo) GetAllPekerjaanAlumni(ctx context.Context) ([]model.PekerjaanAlumni, error) {
     collection := database.MongoDB.Collection("pekerjaan_alumni")
-    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
     defer cancel()
 
     // Ambil hanya dokumen yang belum dihapus DAN alumni_id benar-benar ObjectId
@@ -81,7 +85,7 @@ func (r *PekerjaanAlumniRepositoryMongo) GetAllPekerjaanAlumni() ([]model.Pekerj
         var row model.PekerjaanAlumni
         if err := cursor.Decode(&row); err != nil {
             // Optional: log error lalu lanjut, mirip pola di GetAllUsers
-            // fmt.Printf("[WARNING] decode pekerjaan_alumni gagal: %v\n", err)
+            // slog.Warn("decode pekerjaan_alumni gagal", "error", err)
             continue
         }
         out = append(out, row)
@@ -94,9 +98,9 @@ func (r *PekerjaanAlumniRepositoryMongo) GetAllPekerjaanAlumni() ([]model.Pekerj
 
 
 // GetPekerjaanAlumniByID: pastikan dokumen punya alumni_id bertipe ObjectId juga.
-func (r *PekerjaanAlumniRepositoryMongo) GetPekerjaanAlumniByID(id bson.ObjectID) (*model.PekerjaanAlumni, error) {
+func (r *PekerjaanAlumniRepositoryMongo) GetPekerjaanAlumniByID(ctx context.Context, id bson.ObjectID) (*model.PekerjaanAlumni, error) {
     collection := database.MongoDB.Collection("pekerjaan_alumni")
-    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
     defer cancel()
 
     var pekerjaan model.PekerjaanAlumni
@@ -112,10 +116,30 @@ func (r *PekerjaanAlumniRepositoryMongo) GetPekerjaanAlumniByID(id bson.ObjectID
     return &pekerjaan, nil
 }
 
+// GetPekerjaanOwnerID mengambil alumni_id pemilik pekerjaan, termasuk yang sudah di-soft delete
+// (dipakai pengecekan kepemilikan untuk update, delete, dan restore)
+func (r *PekerjaanAlumniRepositoryMongo) GetPekerjaanOwnerID(ctx context.Context, id bson.ObjectID) (bson.ObjectID, error) {
+	collection := database.MongoDB.Collection("pekerjaan_alumni")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var pekerjaan struct {
+		AlumniID bson.ObjectID `bson:"alumni_id"`
+	}
+	opts := options.FindOne().SetProjection(bson.M{"alumni_id": 1})
+	if err := collection.FindOne(ctx, bson.M{"_id": id}, opts).Decode(&pekerjaan); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return bson.NilObjectID, ErrPekerjaanNotFound
+		}
+		return bson.NilObjectID, err
+	}
+	return pekerjaan.AlumniID, nil
+}
+
 // GetPekerjaanAlumniByAlumniID: equality ke ObjectID sudah aman, tetap iterasi manual biar konsisten.
-func (r *PekerjaanAlumniRepositoryMongo) GetPekerjaanAlumniByAlumniID(alumniID bson.ObjectID) ([]model.PekerjaanAlumni, error) {
+func (r *PekerjaanAlumniRepositoryMongo) GetPekerjaanAlumniByAlumniID(ctx context.Context, alumniID bson.ObjectID) ([]model.PekerjaanAlumni, error) {
     collection := database.MongoDB.Collection("pekerjaan_alumni")
-    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
     defer cancel()
 
     filter := bson.M{
@@ -146,9 +170,9 @@ func (r *PekerjaanAlumniRepositoryMongo) GetPekerjaanAlumniByAlumniID(alumniID b
 
 // repository/pekerjaan_alumni_repository.go
 
-func (r *PekerjaanAlumniRepositoryMongo) UpdatePekerjaanAlumni(id bson.ObjectID, req model.UpdatePekerjaanAlumniRequest) error {
+func (r *PekerjaanAlumniRepositoryMongo) UpdatePekerjaanAlumni(ctx context.Context, id bson.ObjectID, req model.UpdatePekerjaanAlumniRequest) error {
     collection := database.MongoDB.Collection("pekerjaan_alumni")
-    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
     defer cancel()
 
     set := bson.M{}
@@ -202,9 +226,9 @@ func (r *PekerjaanAlumniRepositoryMongo) UpdatePekerjaanAlumni(id bson.ObjectID,
 
 
 // SoftDeletePekerjaanAlumni soft delete pekerjaan alumni (set is_delete)
-func (r *PekerjaanAlumniRepositoryMongo) SoftDeletePekerjaanAlumni(id bson.ObjectID, isDelete bool) error {
+func (r *PekerjaanAlumniRepositoryMongo) SoftDeletePekerjaanAlumni(ctx context.Context, id bson.ObjectID, isDelete bool) error {
 	collection := database.MongoDB.Collection("pekerjaan_alumni")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	deleteStatus := "tidak"
@@ -230,9 +254,9 @@ func (r *PekerjaanAlumniRepositoryMongo) SoftDeletePekerjaanAlumni(id bson.Objec
 }
 
 // GetTrashedPekerjaanAlumni: versi "trash" juga difilter tipe-nya karena struct Trash punya AlumniID: ObjectID.
-func (r *PekerjaanAlumniRepositoryMongo) GetTrashedPekerjaanAlumni() ([]model.Trash, error) {
+func (r *PekerjaanAlumniRepositoryMongo) GetTrashedPekerjaanAlumni(ctx context.Context) ([]model.Trash, error) {
     collection := database.MongoDB.Collection("pekerjaan_alumni")
-    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
     defer cancel()
 
     filter := bson.M{
@@ -262,9 +286,9 @@ func (r *PekerjaanAlumniRepositoryMongo) GetTrashedPekerjaanAlumni() ([]model.Tr
 }
 
 // HardDeleteTrashedPekerjaanAlumni hard delete pekerjaan yang di-trash
-func (r *PekerjaanAlumniRepositoryMongo) HardDeleteTrashedPekerjaanAlumni(id bson.ObjectID) (*model.PekerjaanAlumni, error) {
+func (r *PekerjaanAlumniRepositoryMongo) HardDeleteTrashedPekerjaanAlumni(ctx context.Context, id bson.ObjectID) (*model.PekerjaanAlumni, error) {
 	collection := database.MongoDB.Collection("pekerjaan_alumni")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	var pekerjaan model.PekerjaanAlumni
@@ -281,9 +305,9 @@ func (r *PekerjaanAlumniRepositoryMongo) HardDeleteTrashedPekerjaanAlumni(id bso
 }
 
 // RestoreTrashedPekerjaanAlumni restore pekerjaan dari trash dan kembalikan dokumen terbaru
-func (r *PekerjaanAlumniRepositoryMongo) RestoreTrashedPekerjaanAlumni(id bson.ObjectID) (*model.PekerjaanAlumni, error) {
+func (r *PekerjaanAlumniRepositoryMongo) RestoreTrashedPekerjaanAlumni(ctx context.Context, id bson.ObjectID) (*model.PekerjaanAlumni, error) {
 	collection := database.MongoDB.Collection("pekerjaan_alumni")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	update := bson.M{
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"hello-fiber/app/model"
+	"hello-fiber/database"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("refresh token tidak valid atau kedaluwarsa")
+	ErrRefreshTokenReused  = errors.New("refresh token sudah pernah digunakan")
+)
+
+type RefreshTokenRepositoryMongo struct{}
+
+func NewRefreshTokenRepositoryMongo() *RefreshTokenRepositoryMongo {
+	return &RefreshTokenRepositoryMongo{}
+}
+
+// CreateRefreshToken menyimpan hash refresh token baru
+func (r *RefreshTokenRepositoryMongo) CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error {
+	collection := database.MongoDB.Collection("refresh_tokens")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if token.ID.IsZero() {
+		token.ID = bson.NewObjectID()
+	}
+	token.CreatedAt = time.Now()
+
+	_, err := collection.InsertOne(ctx, token)
+	return err
+}
+
+// ConsumeRefreshToken menandai refresh token sebagai terpakai secara atomik.
+// Jika token ditemukan tetapi sudah terpakai/dicabut, seluruh family dicabut
+// dan ErrRefreshTokenReused dikembalikan.
+func (r *RefreshTokenRepositoryMongo) ConsumeRefreshToken(ctx context.Context, tokenHash string) (*model.RefreshToken, error) {
+	collection := database.MongoDB.Collection("refresh_tokens")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{
+		"token_hash": tokenHash,
+		"used_at":    bson.M{"$exists": false},
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": now},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var token model.RefreshToken
+	err := collection.FindOneAndUpdate(ctx, filter, bson.M{"$set": bson.M{"used_at": now}}, opts).Decode(&token)
+	if err == nil {
+		return &token, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	// Tidak ada token aktif dengan hash ini, cek apakah token lama dipakai ulang
+	if err := collection.FindOne(ctx, bson.M{"token_hash": tokenHash}).Decode(&token); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrRefreshTokenInvalid
+		}
+		return nil, err
+	}
+	if token.UsedAt == nil && token.RevokedAt == nil {
+		// Token hanya kedaluwarsa, bukan dipakai ulang
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	if _, err := collection.UpdateMany(ctx, bson.M{"family_id": token.FamilyID, "revoked_at": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"revoked_at": now}}); err != nil {
+		return nil, err
+	}
+	return &token, ErrRefreshTokenReused
+}
+
+// RevokeFamily mencabut semua refresh token dalam satu family milik userID
+func (r *RefreshTokenRepositoryMongo) RevokeFamily(ctx context.Context, familyID, userID bson.ObjectID) error {
+	collection := database.MongoDB.Collection("refresh_tokens")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := collection.UpdateMany(ctx, bson.M{"family_id": familyID, "user_id": userID, "revoked_at": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"revoked_at": time.Now()}})
+	return err
+}
+
+// RevokeAllForUser mencabut semua refresh token milik user
+func (r *RefreshTokenRepositoryMongo) RevokeAllForUser(ctx context.Context, userID bson.ObjectID) error {
+	collection := database.MongoDB.Collection("refresh_tokens")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := collection.UpdateMany(ctx, bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"revoked_at": time.Now()}})
+	return err
+}
+
+// RevokeFamilyByTokenHash mencabut family dari refresh token milik user tertentu (dipakai saat logout)
+func (r *RefreshTokenRepositoryMongo) RevokeFamilyByTokenHash(ctx context.Context, tokenHash string, userID bson.ObjectID) error {
+	collection := database.MongoDB.Collection("refresh_tokens")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var token model.RefreshToken
+	if err := collection.FindOne(ctx, bson.M{"token_hash": tokenHash, "user_id": userID}).Decode(&token); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ErrRefreshTokenInvalid
+		}
+		return err
+	}
+
+	_, err := collection.UpdateMany(ctx, bson.M{"family_id": token.FamilyID, "revoked_at": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"revoked_at": time.Now()}})
+	return err
+}
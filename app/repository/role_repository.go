@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"hello-fiber/app/model"
+	"hello-fiber/database"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// ErrRoleNotFound dikembalikan jika role dengan ID tersebut tidak ada
+var ErrRoleNotFound = errors.New("role tidak ditemukan")
+
+type RoleRepositoryMongo struct{}
+
+func NewRoleRepositoryMongo() *RoleRepositoryMongo {
+	return &RoleRepositoryMongo{}
+}
+
+// GetRoleByID mengambil role beserta permission-nya
+func (r *RoleRepositoryMongo) GetRoleByID(ctx context.Context, id bson.ObjectID) (*model.Role, error) {
+	collection := database.MongoDB.Collection("roles")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var role model.Role
+	if err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&role); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrRoleNotFound
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+// GetRoleByName mengambil role berdasarkan nama (nil jika tidak ada)
+func (r *RoleRepositoryMongo) GetRoleByName(ctx context.Context, name string) (*model.Role, error) {
+	collection := database.MongoDB.Collection("roles")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var role model.Role
+	if err := collection.FindOne(ctx, bson.M{"role": name}).Decode(&role); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+// GetAllRoles mengambil semua role diurutkan berdasarkan nama
+func (r *RoleRepositoryMongo) GetAllRoles(ctx context.Context) ([]model.Role, error) {
+	collection := database.MongoDB.Collection("roles")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "role", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var roles []model.Role
+	if err := cursor.All(ctx, &roles); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// CreateRole membuat role baru
+func (r *RoleRepositoryMongo) CreateRole(ctx context.Context, req model.CreateRoleRequest) (bson.ObjectID, error) {
+	collection := database.MongoDB.Collection("roles")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	permissions := req.Permissions
+	if permissions == nil {
+		permissions = []string{}
+	}
+
+	role := model.Role{
+		ID:          bson.NewObjectID(),
+		Role:        strings.TrimSpace(req.Role),
+		Permissions: permissions,
+	}
+	if _, err := collection.InsertOne(ctx, role); err != nil {
+		return bson.ObjectID{}, err
+	}
+	return role.ID, nil
+}
+
+// UpdateRole mengganti nama dan/atau permission role
+func (r *RoleRepositoryMongo) UpdateRole(ctx context.Context, id bson.ObjectID, req model.UpdateRoleRequest) error {
+	collection := database.MongoDB.Collection("roles")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := bson.M{}
+	if req.Role != "" {
+		update["role"] = strings.TrimSpace(req.Role)
+	}
+	if req.Permissions != nil {
+		update["permissions"] = *req.Permissions
+	}
+	if len(update) == 0 {
+		return errors.New("tidak ada data yang diupdate")
+	}
+
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": update})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("role tidak ditemukan")
+	}
+	return nil
+}
+
+// DeleteRole menghapus role berdasarkan ID
+func (r *RoleRepositoryMongo) DeleteRole(ctx context.Context, id bson.ObjectID) error {
+	collection := database.MongoDB.Collection("roles")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return errors.New("role tidak ditemukan")
+	}
+	return nil
+}
+
+// WatchRoles membuka change stream pada collection roles. Hanya didukung replica set / sharded
+// cluster, pada server standalone akan mengembalikan error.
+func (r *RoleRepositoryMongo) WatchRoles(ctx context.Context) (*mongo.ChangeStream, error) {
+	collection := database.MongoDB.Collection("roles")
+	return collection.Watch(ctx, mongo.Pipeline{})
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"hello-fiber/app/model"
+	"hello-fiber/database"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type SecurityEventRepositoryMongo struct{}
+
+func NewSecurityEventRepositoryMongo() *SecurityEventRepositoryMongo {
+	return &SecurityEventRepositoryMongo{}
+}
+
+// CreateSecurityEvent menyimpan satu kejadian keamanan
+func (r *SecurityEventRepositoryMongo) CreateSecurityEvent(ctx context.Context, event *model.SecurityEvent) error {
+	collection := database.MongoDB.Collection("security_events")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	result, err := collection.InsertOne(ctx, event)
+	if err != nil {
+		return err
+	}
+	event.ID = result.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+// GetSecurityEvents mengambil kejadian keamanan sesuai filter, terbaru lebih dulu, dengan pagination
+func (r *SecurityEventRepositoryMongo) GetSecurityEvents(ctx context.Context, filter model.SecurityEventFilter, page, limit int64) ([]model.SecurityEvent, int64, error) {
+	collection := database.MongoDB.Collection("security_events")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := bson.M{}
+	if filter.UserID != nil {
+		query["user_id"] = *filter.UserID
+	}
+	if filter.Type != "" {
+		query["type"] = filter.Type
+	}
+	if filter.From != nil || filter.To != nil {
+		createdAt := bson.M{}
+		if filter.From != nil {
+			createdAt["$gte"] = *filter.From
+		}
+		if filter.To != nil {
+			createdAt["$lt"] = *filter.To
+		}
+		query["created_at"] = createdAt
+	}
+
+	total, err := collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetSkip((page - 1) * limit).SetLimit(limit)
+	cursor, err := collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	events := []model.SecurityEvent{}
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"hello-fiber/app/model"
+	"hello-fiber/database"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var ErrSessionNotFound = errors.New("session tidak ditemukan")
+
+type SessionRepositoryMongo struct{}
+
+func NewSessionRepositoryMongo() *SessionRepositoryMongo {
+	return &SessionRepositoryMongo{}
+}
+
+// CreateSession mencatat session baru saat login
+func (r *SessionRepositoryMongo) CreateSession(ctx context.Context, session *model.Session) error {
+	collection := database.MongoDB.Collection("sessions")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	session.CreatedAt = now
+	session.LastSeenAt = now
+
+	_, err := collection.InsertOne(ctx, session)
+	return err
+}
+
+// RefreshSession memperbarui last_seen, IP, user agent, dan masa berlaku session saat refresh token dirotasi
+func (r *SessionRepositoryMongo) RefreshSession(ctx context.Context, id bson.ObjectID, userAgent, ip string, expiresAt time.Time) error {
+	collection := database.MongoDB.Collection("sessions")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id, "revoked_at": bson.M{"$exists": false}}, bson.M{"$set": bson.M{
+		"user_agent":   userAgent,
+		"ip":           ip,
+		"last_seen_at": time.Now(),
+		"expires_at":   expiresAt,
+	}})
+	return err
+}
+
+// TouchSession memperbarui last_seen_at paling sering sekali per `interval`
+func (r *SessionRepositoryMongo) TouchSession(ctx context.Context, id bson.ObjectID, interval time.Duration) error {
+	collection := database.MongoDB.Collection("sessions")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	_, err := collection.UpdateOne(ctx,
+		bson.M{"_id": id, "revoked_at": bson.M{"$exists": false}, "last_seen_at": bson.M{"$lte": now.Add(-interval)}},
+		bson.M{"$set": bson.M{"last_seen_at": now}},
+	)
+	return err
+}
+
+// IsSessionRevoked mengecek apakah session sudah dicabut atau tidak ada lagi
+func (r *SessionRepositoryMongo) IsSessionRevoked(ctx context.Context, id bson.ObjectID) (bool, error) {
+	collection := database.MongoDB.Collection("sessions")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var session model.Session
+	if err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&session); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return true, nil
+		}
+		return false, err
+	}
+	return session.RevokedAt != nil, nil
+}
+
+// GetActiveSessions mengambil session user yang belum dicabut dan belum kedaluwarsa
+func (r *SessionRepositoryMongo) GetActiveSessions(ctx context.Context, userID bson.ObjectID) ([]model.Session, error) {
+	collection := database.MongoDB.Collection("sessions")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"user_id":    userID,
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "last_seen_at", Value: -1}})
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	sessions := []model.Session{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// RevokeSession mencabut satu session milik user. Mengembalikan ErrSessionNotFound
+// jika session tidak ada, bukan milik user, atau sudah dicabut.
+func (r *SessionRepositoryMongo) RevokeSession(ctx context.Context, id, userID bson.ObjectID) error {
+	collection := database.MongoDB.Collection("sessions")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": id, "user_id": userID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeAllForUser mencabut semua session user kecuali `except` (isi NilObjectID untuk mencabut semuanya).
+// Mengembalikan ID session yang dicabut.
+func (r *SessionRepositoryMongo) RevokeAllForUser(ctx context.Context, userID, except bson.ObjectID) ([]bson.ObjectID, error) {
+	collection := database.MongoDB.Collection("sessions")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}}
+	if !except.IsZero() {
+		filter["_id"] = bson.M{"$ne": except}
+	}
+
+	cursor, err := collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []struct {
+		ID bson.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]bson.ObjectID, 0, len(docs))
+	for _, d := range docs {
+		ids = append(ids, d.ID)
+	}
+	if _, err := collection.UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$in": ids}, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"hello-fiber/app/model"
+	"hello-fiber/database"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type TokenRevocationRepositoryMongo struct{}
+
+func NewTokenRevocationRepositoryMongo() *TokenRevocationRepositoryMongo {
+	return &TokenRevocationRepositoryMongo{}
+}
+
+// RevokeToken mencabut satu access token berdasarkan jti sampai token tersebut kedaluwarsa
+func (r *TokenRevocationRepositoryMongo) RevokeToken(ctx context.Context, jti string, userID bson.ObjectID, expiresAt time.Time) error {
+	collection := database.MongoDB.Collection("revoked_tokens")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	doc := model.RevokedToken{
+		JTI:       jti,
+		UserID:    userID,
+		RevokedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+	opts := options.Replace().SetUpsert(true)
+	_, err := collection.ReplaceOne(ctx, bson.M{"_id": jti}, doc, opts)
+	return err
+}
+
+// IsTokenRevoked mengecek apakah jti ada di daftar token yang dicabut
+func (r *TokenRevocationRepositoryMongo) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	collection := database.MongoDB.Collection("revoked_tokens")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := collection.FindOne(ctx, bson.M{"_id": jti}).Err()
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RevokeAllForUser mencabut semua access token user yang diterbitkan sebelum atau pada detik `before`
+func (r *TokenRevocationRepositoryMongo) RevokeAllForUser(ctx context.Context, userID bson.ObjectID, before, expiresAt time.Time) error {
+	collection := database.MongoDB.Collection("user_token_revocations")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	doc := model.UserTokenRevocation{
+		UserID:        userID,
+		RevokedBefore: before,
+		ExpiresAt:     expiresAt,
+	}
+	opts := options.Replace().SetUpsert(true)
+	_, err := collection.ReplaceOne(ctx, bson.M{"_id": userID}, doc, opts)
+	return err
+}
+
+// GetUserRevokedBefore mengambil batas waktu pencabutan token user (nil jika tidak ada)
+func (r *TokenRevocationRepositoryMongo) GetUserRevokedBefore(ctx context.Context, userID bson.ObjectID) (*time.Time, error) {
+	collection := database.MongoDB.Collection("user_token_revocations")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var doc model.UserTokenRevocation
+	if err := collection.FindOne(ctx, bson.M{"_id": userID}).Decode(&doc); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &doc.RevokedBefore, nil
+}
@@ -3,32 +3,38 @@ package repository
 import (
 	"context"
 	"errors"
-	"fmt"
+	"log/slog"
 	"time"
 
 	"hello-fiber/app/model"
 	"hello-fiber/database"
+	"hello-fiber/utils"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
-	"golang.org/x/crypto/bcrypt"
 	"strings"
 )
 
+// ErrUserNotFound dikembalikan jika user dengan ID tersebut tidak ada
+var ErrUserNotFound = errors.New("user tidak ditemukan")
+
+// ErrUserExists dikembalikan jika email atau username sudah dipakai user lain (unique index)
+var ErrUserExists = errors.New("email atau username sudah terdaftar")
+
 type UserRepositoryMongo struct{}
 
 func NewUserRepositoryMongo() *UserRepositoryMongo {
 	return &UserRepositoryMongo{}
 }
 
-func (r *UserRepositoryMongo) Register(req model.RegisterRequest) (bson.ObjectID, error) {
+func (r *UserRepositoryMongo) Register(ctx context.Context, req model.RegisterRequest) (bson.ObjectID, error) {
 	collection := database.MongoDB.Collection("users")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	// Hash password sebelum disimpan
-	hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashed, err := utils.HashPassword(req.Password)
 	if err != nil {
 		return bson.ObjectID{}, err
 	}
@@ -37,15 +43,16 @@ func (r *UserRepositoryMongo) Register(req model.RegisterRequest) (bson.ObjectID
 		ID:        bson.NewObjectID(),
 		Username:  strings.TrimSpace(req.Username),
 		Email:     strings.ToLower(strings.TrimSpace(req.Email)),
-		Password:  string(hashed),
+		Password:  hashed,
 		RoleID:    req.RoleID,
+		AlumniID:  req.AlumniID,
 		CreatedAt: time.Now(),
 	}
 
 	result, err := collection.InsertOne(ctx, user)
 	if err != nil {
 		if mongo.IsDuplicateKeyError(err) {
-			return bson.ObjectID{}, errors.New("email atau username sudah terdaftar")
+			return bson.ObjectID{}, ErrUserExists
 		}
 		return bson.ObjectID{}, err
 	}
@@ -53,13 +60,13 @@ func (r *UserRepositoryMongo) Register(req model.RegisterRequest) (bson.ObjectID
 	return result.InsertedID.(bson.ObjectID), nil
 }
 
-func (r *UserRepositoryMongo) CreateUser(req model.CreateUserRequest) (bson.ObjectID, error) {
+func (r *UserRepositoryMongo) CreateUser(ctx context.Context, req model.CreateUserRequest) (bson.ObjectID, error) {
 	collection := database.MongoDB.Collection("users")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	// Hash password sebelum disimpan
-	hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashed, err := utils.HashPassword(req.Password)
 	if err != nil {
 		return bson.ObjectID{}, err
 	}
@@ -68,16 +75,21 @@ func (r *UserRepositoryMongo) CreateUser(req model.CreateUserRequest) (bson.Obje
 		ID:        bson.NewObjectID(),
 		Username:  strings.TrimSpace(req.Username),
 		Email:     strings.ToLower(strings.TrimSpace(req.Email)),
-		Password:  string(hashed),
+		Password:  hashed,
 		RoleID:    req.RoleID,
 		AlumniID:  req.AlumniID,
 		CreatedAt: time.Now(),
 	}
+	if req.SkipVerification {
+		now := time.Now()
+		user.EmailVerified = true
+		user.EmailVerifiedAt = &now
+	}
 
 	result, err := collection.InsertOne(ctx, user)
 	if err != nil {
 		if mongo.IsDuplicateKeyError(err) {
-			return bson.ObjectID{}, errors.New("email atau username sudah terdaftar")
+			return bson.ObjectID{}, ErrUserExists
 		}
 		return bson.ObjectID{}, err
 	}
@@ -86,15 +98,15 @@ func (r *UserRepositoryMongo) CreateUser(req model.CreateUserRequest) (bson.Obje
 }
 
 // GetUserByEmail mengambil user berdasarkan email (untuk login)
-func (r *UserRepositoryMongo) GetUserByEmail(email string) (*model.User, error) {
+func (r *UserRepositoryMongo) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
 	collection := database.MongoDB.Collection("users")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	var user model.User
 	if err := collection.FindOne(ctx, bson.M{"email": email}).Decode(&user); err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, errors.New("user tidak ditemukan")
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
@@ -102,24 +114,24 @@ func (r *UserRepositoryMongo) GetUserByEmail(email string) (*model.User, error)
 }
 
 // GetUserByID mengambil user berdasarkan ID
-func (r *UserRepositoryMongo) GetUserByID(id bson.ObjectID) (*model.User, error) {
+func (r *UserRepositoryMongo) GetUserByID(ctx context.Context, id bson.ObjectID) (*model.User, error) {
 	collection := database.MongoDB.Collection("users")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	var user model.User
 	if err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&user); err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, errors.New("user tidak ditemukan")
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *UserRepositoryMongo) GetUserByUsername(username string) (*model.User, error) {
+func (r *UserRepositoryMongo) GetUserByUsername(ctx context.Context, username string) (*model.User, error) {
 	collection := database.MongoDB.Collection("users")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	var user model.User
@@ -132,9 +144,9 @@ func (r *UserRepositoryMongo) GetUserByUsername(username string) (*model.User, e
 	return &user, nil
 }
 
-func (r *UserRepositoryMongo) GetAllUsers(page, limit int64) ([]model.User, int64, error) {
+func (r *UserRepositoryMongo) GetAllUsers(ctx context.Context, page, limit int64) ([]model.User, int64, error) {
 	collection := database.MongoDB.Collection("users")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	// Hitung total users
@@ -156,7 +168,7 @@ func (r *UserRepositoryMongo) GetAllUsers(page, limit int64) ([]model.User, int6
 		var user model.User
 		if err := cursor.Decode(&user); err != nil {
 			// Log error but continue processing other documents
-			fmt.Printf("[WARNING] Failed to decode user document: %v\n", err)
+			slog.Warn("Failed to decode user document", "error", err)
 			continue
 		}
 		users = append(users, user)
@@ -169,9 +181,9 @@ func (r *UserRepositoryMongo) GetAllUsers(page, limit int64) ([]model.User, int6
 	return users, total, nil
 }
 
-func (r *UserRepositoryMongo) UpdateUser(id bson.ObjectID, req model.UpdateUserRequest) error {
+func (r *UserRepositoryMongo) UpdateUser(ctx context.Context, id bson.ObjectID, req model.UpdateUserRequest) error {
 	collection := database.MongoDB.Collection("users")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	update := bson.M{}
@@ -183,11 +195,11 @@ func (r *UserRepositoryMongo) UpdateUser(id bson.ObjectID, req model.UpdateUserR
 	}
 	if req.Password != "" {
 		// Hash password sebelum disimpan
-		hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		hashed, err := utils.HashPassword(req.Password)
 		if err != nil {
 			return err
 		}
-		update["password"] = string(hashed)
+		update["password"] = hashed
 	}
 	if req.RoleID != bson.NilObjectID {
 		update["role_id"] = req.RoleID
@@ -203,7 +215,7 @@ func (r *UserRepositoryMongo) UpdateUser(id bson.ObjectID, req model.UpdateUserR
 	result, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": update})
 	if err != nil {
 		if mongo.IsDuplicateKeyError(err) {
-			return errors.New("email atau username sudah terdaftar")
+			return ErrUserExists
 		}
 		return err
 	}
@@ -215,9 +227,9 @@ func (r *UserRepositoryMongo) UpdateUser(id bson.ObjectID, req model.UpdateUserR
 	return nil
 }
 
-func (r *UserRepositoryMongo) DeleteUser(id bson.ObjectID) error {
+func (r *UserRepositoryMongo) DeleteUser(ctx context.Context, id bson.ObjectID) error {
 	collection := database.MongoDB.Collection("users")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	result, err := collection.DeleteOne(ctx, bson.M{"_id": id})
@@ -232,9 +244,9 @@ func (r *UserRepositoryMongo) DeleteUser(id bson.ObjectID) error {
 	return nil
 }
 
-func (r *UserRepositoryMongo) GetRoleByID(id bson.ObjectID) (*model.Role, error) {
+func (r *UserRepositoryMongo) GetRoleByID(ctx context.Context, id bson.ObjectID) (*model.Role, error) {
 	collection := database.MongoDB.Collection("roles")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	var role model.Role
@@ -245,4 +257,397 @@ func (r *UserRepositoryMongo) GetRoleByID(id bson.ObjectID) (*model.Role, error)
 		return nil, err
 	}
 	return &role, nil
-}
\ No newline at end of file
+}
+
+// MarkEmailVerified menandai email user sebagai terverifikasi, hanya jika email masih sama dengan di token
+func (r *UserRepositoryMongo) MarkEmailVerified(ctx context.Context, id bson.ObjectID, email string) error {
+	collection := database.MongoDB.Collection("users")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": id, "email": email}, bson.M{"$set": bson.M{
+		"email_verified":    true,
+		"email_verified_at": time.Now(),
+	}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("user tidak ditemukan atau email sudah berubah")
+	}
+	return nil
+}
+
+// ClaimVerificationSend mencatat waktu pengiriman email verifikasi secara atomik.
+// Mengembalikan false jika email terakhir dikirim kurang dari `interval` yang lalu.
+func (r *UserRepositoryMongo) ClaimVerificationSend(ctx context.Context, id bson.ObjectID, interval time.Duration) (bool, error) {
+	collection := database.MongoDB.Collection("users")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{
+		"_id": id,
+		"$or": bson.A{
+			bson.M{"verification_sent_at": bson.M{"$exists": false}},
+			bson.M{"verification_sent_at": bson.M{"$lte": now.Add(-interval)}},
+		},
+	}
+	result, err := collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"verification_sent_at": now}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// ClaimPasswordResetSend mencatat waktu pengiriman email reset password secara atomik.
+// Mengembalikan false jika email terakhir dikirim kurang dari `interval` yang lalu.
+func (r *UserRepositoryMongo) ClaimPasswordResetSend(ctx context.Context, id bson.ObjectID, interval time.Duration) (bool, error) {
+	collection := database.MongoDB.Collection("users")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{
+		"_id": id,
+		"$or": bson.A{
+			bson.M{"password_reset_sent_at": bson.M{"$exists": false}},
+			bson.M{"password_reset_sent_at": bson.M{"$lte": now.Add(-interval)}},
+		},
+	}
+	result, err := collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"password_reset_sent_at": now}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// SetPendingTOTPSecret menyimpan secret TOTP yang belum dikonfirmasi user
+func (r *UserRepositoryMongo) SetPendingTOTPSecret(ctx context.Context, id bson.ObjectID, secret string) error {
+	collection := database.MongoDB.Collection("users")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"totp_pending_secret": secret}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("user tidak ditemukan")
+	}
+	return nil
+}
+
+// EnableTOTP mengaktifkan 2FA dengan secret yang sudah dikonfirmasi dan hash kode pemulihan
+func (r *UserRepositoryMongo) EnableTOTP(ctx context.Context, id bson.ObjectID, secret string, step int64, recoveryHashes []string) error {
+	collection := database.MongoDB.Collection("users")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{
+			"totp_enabled":   true,
+			"totp_secret":    secret,
+			"totp_last_step": step,
+			"recovery_codes": recoveryHashes,
+		},
+		"$unset": bson.M{"totp_pending_secret": ""},
+	})
+	return err
+}
+
+// DisableTOTP menonaktifkan 2FA dan menghapus secret serta kode pemulihan
+func (r *UserRepositoryMongo) DisableTOTP(ctx context.Context, id bson.ObjectID) error {
+	collection := database.MongoDB.Collection("users")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set":   bson.M{"totp_enabled": false},
+		"$unset": bson.M{"totp_secret": "", "totp_pending_secret": "", "totp_last_step": "", "recovery_codes": ""},
+	})
+	return err
+}
+
+// ConsumeTOTPStep mencatat time-step TOTP yang dipakai; false jika step tersebut (atau yang lebih baru) sudah dipakai
+func (r *UserRepositoryMongo) ConsumeTOTPStep(ctx context.Context, id bson.ObjectID, step int64) (bool, error) {
+	collection := database.MongoDB.Collection("users")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"_id": id,
+		"$or": bson.A{
+			bson.M{"totp_last_step": bson.M{"$exists": false}},
+			bson.M{"totp_last_step": bson.M{"$lt": step}},
+		},
+	}
+	result, err := collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"totp_last_step": step}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// ConsumeRecoveryCode menghapus hash kode pemulihan jika ada; false jika kode tidak valid/sudah dipakai
+func (r *UserRepositoryMongo) ConsumeRecoveryCode(ctx context.Context, id bson.ObjectID, codeHash string) (bool, error) {
+	collection := database.MongoDB.Collection("users")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": id, "recovery_codes": codeHash}, bson.M{"$pull": bson.M{"recovery_codes": codeHash}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// SetRecoveryCodes mengganti seluruh kode pemulihan user
+func (r *UserRepositoryMongo) SetRecoveryCodes(ctx context.Context, id bson.ObjectID, recoveryHashes []string) error {
+	collection := database.MongoDB.Collection("users")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"recovery_codes": recoveryHashes}})
+	return err
+}
+
+// CountUsersByRole menghitung jumlah user yang memakai role tertentu
+func (r *UserRepositoryMongo) CountUsersByRole(ctx context.Context, roleID bson.ObjectID) (int64, error) {
+	collection := database.MongoDB.Collection("users")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return collection.CountDocuments(ctx, bson.M{"role_id": roleID})
+}
+
+// ReassignRole memindahkan semua user dari satu role ke role lain, mengembalikan ID user yang dipindah
+func (r *UserRepositoryMongo) ReassignRole(ctx context.Context, fromRoleID, toRoleID bson.ObjectID) ([]bson.ObjectID, error) {
+	collection := database.MongoDB.Collection("users")
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	cursor, err := collection.Find(ctx, bson.M{"role_id": fromRoleID}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []struct {
+		ID bson.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]bson.ObjectID, 0, len(docs))
+	for _, d := range docs {
+		ids = append(ids, d.ID)
+	}
+	if _, err := collection.UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$in": ids}, "role_id": fromRoleID},
+		bson.M{"$set": bson.M{"role_id": toRoleID}},
+	); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// GetUserByAlumniID mengambil user yang tertaut ke alumni tertentu (nil jika belum ada)
+func (r *UserRepositoryMongo) GetUserByAlumniID(ctx context.Context, alumniID bson.ObjectID) (*model.User, error) {
+	collection := database.MongoDB.Collection("users")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var user model.User
+	if err := collection.FindOne(ctx, bson.M{"alumni_id": alumniID}).Decode(&user); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateProfile mengubah username/email milik user sendiri.
+// Jika email berubah, status verifikasi di-reset sehingga email baru harus diverifikasi ulang.
+func (r *UserRepositoryMongo) UpdateProfile(ctx context.Context, id bson.ObjectID, req model.UpdateMeRequest, emailChanged bool) error {
+	collection := database.MongoDB.Collection("users")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	set := bson.M{}
+	if req.Username != "" {
+		set["username"] = strings.TrimSpace(req.Username)
+	}
+	update := bson.M{}
+	if emailChanged {
+		set["email"] = strings.ToLower(strings.TrimSpace(req.Email))
+		set["email_verified"] = false
+		update["$unset"] = bson.M{"email_verified_at": "", "verification_sent_at": ""}
+	}
+	if len(set) == 0 {
+		return errors.New("tidak ada data yang diupdate")
+	}
+	update["$set"] = set
+
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrUserExists
+		}
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("user tidak ditemukan")
+	}
+	return nil
+}
+
+// GetUserByOIDCSubject mengambil user yang tertaut ke identitas IdP (nil jika belum ada)
+func (r *UserRepositoryMongo) GetUserByOIDCSubject(ctx context.Context, issuer, subject string) (*model.User, error) {
+	collection := database.MongoDB.Collection("users")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var user model.User
+	if err := collection.FindOne(ctx, bson.M{"oidc_issuer": issuer, "oidc_subject": subject}).Decode(&user); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// LinkOIDCIdentity menautkan identitas IdP ke user yang belum tertaut ke identitas lain.
+// Email ikut ditandai terverifikasi karena IdP sudah memverifikasinya.
+func (r *UserRepositoryMongo) LinkOIDCIdentity(ctx context.Context, id bson.ObjectID, issuer, subject string) error {
+	collection := database.MongoDB.Collection("users")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": id, "oidc_subject": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"oidc_issuer": issuer, "oidc_subject": subject, "email_verified": true}},
+	)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return errors.New("identitas OIDC sudah tertaut ke akun lain")
+		}
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("akun sudah tertaut ke identitas OIDC lain")
+	}
+	return nil
+}
+
+// CreateOIDCUser membuat user baru dari identitas IdP. User ini tidak punya password lokal
+// (login password selalu gagal sampai user memakai lupa password).
+func (r *UserRepositoryMongo) CreateOIDCUser(ctx context.Context, username, email string, roleID bson.ObjectID, issuer, subject string) (*model.User, error) {
+	collection := database.MongoDB.Collection("users")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	user := model.User{
+		ID:              bson.NewObjectID(),
+		Username:        username,
+		Email:           strings.ToLower(strings.TrimSpace(email)),
+		RoleID:          roleID,
+		CreatedAt:       now,
+		EmailVerified:   true,
+		EmailVerifiedAt: &now,
+		OIDCIssuer:      issuer,
+		OIDCSubject:     subject,
+	}
+	if _, err := collection.InsertOne(ctx, user); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrUserExists
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ChangePassword mengganti password user dan memindahkan hash lama ke password_history
+// (hanya `historyLimit` hash terakhir yang disimpan, 0 berarti riwayat tidak disimpan)
+func (r *UserRepositoryMongo) ChangePassword(ctx context.Context, id bson.ObjectID, password string, historyLimit int) error {
+	collection := database.MongoDB.Collection("users")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	hashed, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	// Pipeline update: "$password" masih berisi hash lama saat stage ini dievaluasi
+	var history interface{} = bson.A{}
+	if historyLimit > 0 {
+		history = bson.M{"$slice": bson.A{
+			bson.M{"$concatArrays": bson.A{
+				bson.M{"$ifNull": bson.A{"$password_history", bson.A{}}},
+				bson.A{"$password"},
+			}},
+			-historyLimit,
+		}}
+	}
+	update := bson.A{bson.M{"$set": bson.M{
+		"password_history": history,
+		"password":         hashed,
+	}}}
+
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("user tidak ditemukan")
+	}
+	return nil
+}
+
+// UpgradePasswordHash mengganti hash password dengan hash baru untuk password yang sama
+// (mis. migrasi bcrypt ke Argon2id). Hanya berhasil jika hash lama belum berubah.
+func (r *UserRepositoryMongo) UpgradePasswordHash(ctx context.Context, id bson.ObjectID, oldHash, newHash string) error {
+	collection := database.MongoDB.Collection("users")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id, "password": oldHash}, bson.M{"$set": bson.M{"password": newHash}})
+	return err
+}
+
+// SetUserStatus mengubah status akun. Untuk status selain suspended, alasan dan batas waktu suspend dihapus.
+func (r *UserRepositoryMongo) SetUserStatus(ctx context.Context, id bson.ObjectID, status, reason string, until *time.Time) error {
+	collection := database.MongoDB.Collection("users")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := bson.M{}
+	if status == model.UserStatusSuspended {
+		set := bson.M{"status": status, "suspended_reason": reason}
+		if until != nil {
+			set["suspended_until"] = *until
+			update["$set"] = set
+		} else {
+			update["$set"] = set
+			update["$unset"] = bson.M{"suspended_until": ""}
+		}
+	} else {
+		update["$set"] = bson.M{"status": status}
+		update["$unset"] = bson.M{"suspended_reason": "", "suspended_until": ""}
+	}
+
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("user tidak ditemukan")
+	}
+	return nil
+}
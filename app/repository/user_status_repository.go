@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"hello-fiber/app/model"
+	"hello-fiber/database"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type UserStatusRepositoryMongo struct{}
+
+func NewUserStatusRepositoryMongo() *UserStatusRepositoryMongo {
+	return &UserStatusRepositoryMongo{}
+}
+
+// CreateStatusEvent mencatat perubahan status akun
+func (r *UserStatusRepositoryMongo) CreateStatusEvent(ctx context.Context, event *model.UserStatusEvent) error {
+	collection := database.MongoDB.Collection("user_status_events")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	event.CreatedAt = time.Now()
+	result, err := collection.InsertOne(ctx, event)
+	if err != nil {
+		return err
+	}
+	event.ID = result.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+// GetStatusEvents mengambil riwayat perubahan status akun user, terbaru lebih dulu
+func (r *UserStatusRepositoryMongo) GetStatusEvents(ctx context.Context, userID bson.ObjectID) ([]model.UserStatusEvent, error) {
+	collection := database.MongoDB.Collection("user_status_events")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	cursor, err := collection.Find(ctx, bson.M{"user_id": userID}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	events := []model.UserStatusEvent{}
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
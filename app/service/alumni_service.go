@@ -27,7 +27,7 @@ func GetAllAlumniService(c *fiber.Ctx) error {
 		limit = 10
 	}
 
-	alumni, total, err := alumniRepo.GetAllAlumniWithPagination(page, limit)
+	alumni, total, err := alumniRepo.GetAllAlumniWithPagination(c.UserContext(), page, limit)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mengambil data alumni", "error": err.Error()})
 	}
@@ -54,7 +54,7 @@ func GetAlumniByIDService(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Format ID tidak valid"})
 	}
 
-	item, err := alumniRepo.GetAlumniByID(id)
+	item, err := alumniRepo.GetAlumniByID(c.UserContext(), id)
 	if err != nil {
 		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Alumni tidak ditemukan", "error": err.Error()})
 	}
@@ -73,7 +73,7 @@ func CreateAlumniService(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"success": false, "message": "NIM, nama, jurusan, dan email harus diisi"})
 	}
 
-	id, err := alumniRepo.CreateAlumni(req)
+	id, err := alumniRepo.CreateAlumni(c.UserContext(), req)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal menambahkan alumni", "error": err.Error()})
 	}
@@ -104,7 +104,7 @@ func UpdateAlumniService(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Minimal ada satu field yang harus diupdate"})
 	}
 
-	if err := alumniRepo.UpdateAlumni(id, req); err != nil {
+	if err := alumniRepo.UpdateAlumni(c.UserContext(), id, req); err != nil {
 		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Gagal mengupdate alumni", "error": err.Error()})
 	}
 	return c.JSON(fiber.Map{"success": true, "message": "Alumni berhasil diupdate"})
@@ -122,7 +122,7 @@ func DeleteAlumniService(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Format ID tidak valid"})
 	}
 
-	if err := alumniRepo.DeleteAlumni(id); err != nil {
+	if err := alumniRepo.DeleteAlumni(c.UserContext(), id); err != nil {
 		// Check if it's a "not found" error
 		if err.Error() == "alumni not found" {
 			return c.Status(404).JSON(fiber.Map{"success": false, "message": "Alumni tidak ditemukan", "error": err.Error()})
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"hello-fiber/app/model"
+	"hello-fiber/app/repository"
+	"hello-fiber/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var apiKeyRepo = repository.NewAPIKeyRepositoryMongo()
+
+// ErrAPIKeyOwnerNotAllowed dikembalikan jika pembuat api key sudah tidak aktif atau
+// role-nya tidak lagi mencakup hak akses key tersebut
+var ErrAPIKeyOwnerNotAllowed = errors.New("pemilik api key tidak aktif atau tidak lagi memiliki hak akses key")
+
+// apiKeyPrefix menandai string sebagai api key aplikasi ini
+const apiKeyPrefix = "ak_"
+
+// APIKeyHeader adalah nama header tempat client mengirim api key (API_KEY_HEADER, default X-API-Key)
+func APIKeyHeader() string {
+	return utils.GetEnv("API_KEY_HEADER", "X-API-Key")
+}
+
+// AuthenticateAPIKey memvalidasi api key mentah dan mengembalikan role efektifnya.
+// Key yang dipetakan ke role memakai permission role saat ini; key dengan daftar
+// permission memakai role sintetis berisi permission tersebut. Key ditolak jika pembuatnya
+// disuspend, dinonaktifkan, dihapus, atau role-nya saat ini tidak lagi mencakup hak akses key.
+func AuthenticateAPIKey(ctx context.Context, raw string) (*model.APIKey, *model.Role, error) {
+	if !strings.HasPrefix(raw, apiKeyPrefix) {
+		return nil, nil, repository.ErrAPIKeyInvalid
+	}
+
+	key, err := apiKeyRepo.GetActiveAPIKeyByHash(ctx, utils.HashOpaqueToken(raw))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var role *model.Role
+	if key.RoleID != nil {
+		if role, err = getRole(ctx, *key.RoleID); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		role = &model.Role{Role: "api-key:" + key.Name, Permissions: key.Permissions}
+	}
+
+	owner, err := userAccess(ctx, key.CreatedBy)
+	if err != nil {
+		return nil, nil, err
+	}
+	if owner.blocked {
+		return nil, nil, ErrAPIKeyOwnerNotAllowed
+	}
+	ownerRole, err := getRole(ctx, owner.roleID)
+	if errors.Is(err, repository.ErrRoleNotFound) {
+		return nil, nil, ErrAPIKeyOwnerNotAllowed
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if !roleCovers(ownerRole, role) {
+		return nil, nil, ErrAPIKeyOwnerNotAllowed
+	}
+
+	_ = apiKeyRepo.TouchAPIKey(ctx, key.ID, utils.GetEnvDuration("API_KEY_TOUCH_INTERVAL", time.Minute))
+	return key, role, nil
+}
+
+// CreateAPIKeyService (admin) membuat api key baru. Key mentah hanya dikembalikan sekali di response ini.
+func CreateAPIKeyService(c *fiber.Ctx) error {
+	adminID, err := currentUserID(c)
+	if err != nil {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Api key hanya bisa dibuat oleh user yang login, bukan oleh api key lain"})
+	}
+
+	var req model.CreateAPIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Request body tidak valid", "error": err.Error()})
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if len(req.Name) < 2 || len(req.Name) > 100 {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Nama api key harus 2-100 karakter"})
+	}
+
+	if (req.RoleID == nil) == (len(req.Permissions) == 0) {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Isi salah satu dari role_id atau permissions"})
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "expires_at harus di masa depan"})
+	}
+
+	// Hak akses key tidak boleh melebihi hak akses pembuatnya
+	var scope *model.Role
+	if req.RoleID != nil {
+		role, err := roleRepo.GetRoleByID(c.UserContext(), *req.RoleID)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"success": false, "message": "Role ID tidak valid", "error": err.Error()})
+		}
+		scope = role
+	} else {
+		if invalid := invalidPermissions(req.Permissions); len(invalid) > 0 {
+			return c.Status(400).JSON(fiber.Map{"success": false, "message": "Permission tidak dikenali", "invalid_permissions": invalid})
+		}
+		scope = &model.Role{Permissions: req.Permissions}
+	}
+	if !canGrantRole(c, scope) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Tidak boleh membuat api key dengan akses lebih tinggi dari role Anda"})
+	}
+
+	token, _, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal membuat api key", "error": err.Error()})
+	}
+	raw := apiKeyPrefix + token
+
+	key := &model.APIKey{
+		Name:        req.Name,
+		Prefix:      raw[:len(apiKeyPrefix)+6],
+		KeyHash:     utils.HashOpaqueToken(raw),
+		RoleID:      req.RoleID,
+		Permissions: req.Permissions,
+		CreatedBy:   adminID,
+		ExpiresAt:   req.ExpiresAt,
+	}
+	if err := apiKeyRepo.CreateAPIKey(c.UserContext(), key); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal menyimpan api key", "error": err.Error()})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"message": "Api key berhasil dibuat, simpan key ini karena tidak akan ditampilkan lagi",
+		"key":     raw,
+		"data":    key,
+	})
+}
+
+// GetAllAPIKeysService (admin) menampilkan daftar api key tanpa nilai key-nya
+func GetAllAPIKeysService(c *fiber.Ctx) error {
+	keys, err := apiKeyRepo.GetAllAPIKeys(c.UserContext())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mengambil data api key", "error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"success": true, "message": "Data api key berhasil diambil", "data": keys})
+}
+
+// RevokeAPIKeyService (admin) mencabut api key sehingga langsung tidak bisa dipakai lagi
+func RevokeAPIKeyService(c *fiber.Ctx) error {
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "API key ID tidak valid"})
+	}
+
+	if err := apiKeyRepo.RevokeAPIKey(c.UserContext(), id); err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "message": "Api key berhasil dicabut"})
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"hello-fiber/app/model"
+	"hello-fiber/utils"
+)
+
+func createTestAPIKey(t *testing.T, owner *model.User, permissions ...string) string {
+	t.Helper()
+	token, _, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := apiKeyPrefix + token
+	key := &model.APIKey{
+		Name:        "test",
+		Prefix:      raw[:len(apiKeyPrefix)+6],
+		KeyHash:     utils.HashOpaqueToken(raw),
+		Permissions: permissions,
+		CreatedBy:   owner.ID,
+	}
+	if err := apiKeyRepo.CreateAPIKey(context.Background(), key); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = apiKeyRepo.RevokeAPIKey(context.Background(), key.ID) })
+	return raw
+}
+
+func TestAuthenticateAPIKeyChecksOwner(t *testing.T) {
+	requireTestMongo(t)
+	ctx := context.Background()
+
+	owner := createTestUser(t)
+	ownerRole, err := getRole(ctx, owner.RoleID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ownerRole.Permissions) == 0 || ownerRole.HasAllPermissions() {
+		t.Skip("DEFAULT_ROLE harus punya permission terbatas untuk test ini")
+	}
+
+	withinRole := createTestAPIKey(t, owner, ownerRole.Permissions[0])
+	if _, _, err := AuthenticateAPIKey(ctx, withinRole); err != nil {
+		t.Fatalf("key dalam batas role pemilik ditolak: %v", err)
+	}
+
+	aboveRole := createTestAPIKey(t, owner, model.PermissionAll)
+	if _, _, err := AuthenticateAPIKey(ctx, aboveRole); !errors.Is(err, ErrAPIKeyOwnerNotAllowed) {
+		t.Fatalf("key melebihi role pemilik: err = %v, want ErrAPIKeyOwnerNotAllowed", err)
+	}
+
+	if err := setUserStatus(ctx, owner, owner.ID, "suspend", model.UserStatusSuspended, "test", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := AuthenticateAPIKey(ctx, withinRole); !errors.Is(err, ErrAPIKeyOwnerNotAllowed) {
+		t.Fatalf("pemilik disuspend: err = %v, want ErrAPIKeyOwnerNotAllowed", err)
+	}
+}
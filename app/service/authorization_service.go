@@ -0,0 +1,59 @@
+package service
+
+import (
+	"hello-fiber/app/model"
+	"hello-fiber/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var roleRepo = repository.NewRoleRepositoryMongo()
+
+// CurrentRole mengambil role user yang sedang login dari role cache (hasilnya disimpan di locals selama request)
+func CurrentRole(c *fiber.Ctx) (*model.Role, error) {
+	if role, ok := c.Locals("role").(*model.Role); ok {
+		return role, nil
+	}
+
+	roleIDStr, _ := c.Locals("role_id").(string)
+	roleID, err := bson.ObjectIDFromHex(roleIDStr)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := getRole(c.UserContext(), roleID)
+	if err != nil {
+		return nil, err
+	}
+	c.Locals("role", role)
+	return role, nil
+}
+
+// HasPermission mengecek permission user yang sedang login
+func HasPermission(c *fiber.Ctx, permission string) bool {
+	role, err := CurrentRole(c)
+	if err != nil {
+		return false
+	}
+	return role.HasPermission(permission)
+}
+
+// canGrantRole mencegah user memberikan role yang lebih tinggi dari role miliknya sendiri
+func canGrantRole(c *fiber.Ctx, target *model.Role) bool {
+	current, err := CurrentRole(c)
+	if err != nil {
+		return false
+	}
+	return roleCovers(current, target)
+}
+
+// roleCovers mengecek apakah holder memiliki semua permission target
+func roleCovers(holder, target *model.Role) bool {
+	for _, p := range target.Permissions {
+		if !holder.HasPermission(p) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"hello-fiber/app/model"
+	"hello-fiber/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Kebijakan login untuk user yang emailnya belum diverifikasi (EMAIL_VERIFICATION_POLICY):
+//
+//	off    - tidak ada pembatasan
+//	limit  - boleh login, tetapi hanya bisa melakukan request baca (default)
+//	reject - login ditolak sampai email diverifikasi
+const (
+	EmailVerificationOff    = "off"
+	EmailVerificationLimit  = "limit"
+	EmailVerificationReject = "reject"
+)
+
+func EmailVerificationPolicy() string {
+	switch policy := strings.ToLower(utils.GetEnv("EMAIL_VERIFICATION_POLICY", EmailVerificationLimit)); policy {
+	case EmailVerificationOff, EmailVerificationReject:
+		return policy
+	default:
+		return EmailVerificationLimit
+	}
+}
+
+// sendVerificationEmail mengirim link verifikasi, dibatasi satu kali per EMAIL_VERIFICATION_RESEND_INTERVAL
+func sendVerificationEmail(ctx context.Context, user *model.User) error {
+	interval := utils.GetEnvDuration("EMAIL_VERIFICATION_RESEND_INTERVAL", time.Minute)
+	allowed, err := userRepo.ClaimVerificationSend(ctx, user.ID, interval)
+	if err != nil || !allowed {
+		return err
+	}
+
+	token, err := utils.GenerateEmailVerificationToken(*user)
+	if err != nil {
+		return err
+	}
+
+	link := utils.GetEnv("EMAIL_VERIFICATION_URL", "http://localhost:3000/api/verify-email") + "?token=" + token
+	body := "Halo " + user.Username + ",\n\n" +
+		"Silakan verifikasi alamat email Anda dengan membuka link berikut:\n\n" +
+		link + "\n\n" +
+		"Abaikan email ini jika Anda tidak merasa mendaftar."
+
+	return utils.GetMailer().Send(user.Email, "Verifikasi Email", body)
+}
+
+// VerifyEmailService memproses link verifikasi email (GET /api/verify-email?token=...)
+func VerifyEmailService(c *fiber.Ctx) error {
+	tokenString := c.Query("token")
+	if tokenString == "" {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Token verifikasi harus diisi"})
+	}
+
+	claims, err := utils.ParseEmailVerificationToken(tokenString)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Token verifikasi tidak valid atau kedaluwarsa"})
+	}
+
+	id, err := bson.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Token verifikasi tidak valid"})
+	}
+
+	if err := userRepo.MarkEmailVerified(c.UserContext(), id, claims.Email); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Gagal verifikasi email", "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "message": "Email berhasil diverifikasi"})
+}
+
+// ResendVerificationService mengirim ulang email verifikasi.
+// Response selalu sama supaya tidak bisa dipakai untuk mengecek email terdaftar.
+func ResendVerificationService(c *fiber.Ctx) error {
+	var req model.ResendVerificationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Request body tidak valid", "error": err.Error()})
+	}
+
+	if req.Email == "" || !isValidEmail(req.Email) {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Format email tidak valid"})
+	}
+
+	user, err := userRepo.GetUserByEmail(c.UserContext(), strings.ToLower(strings.TrimSpace(req.Email)))
+	if err == nil && !user.EmailVerified {
+		if err := sendVerificationEmail(c.UserContext(), user); err != nil {
+			requestLogger(c).Error("Error sending verification email", "error", err)
+		}
+	}
+
+	return c.JSON(fiber.Map{"success": true, "message": "Jika email terdaftar dan belum diverifikasi, link verifikasi telah dikirim"})
+}
@@ -1,12 +1,12 @@
 package service
 
 import (
-	"fmt"
 	"os"
 	"path/filepath"
 
 	"hello-fiber/app/model"
 	"hello-fiber/app/repository"
+	"hello-fiber/utils"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -46,6 +46,7 @@ func (s *fileUploadService) UploadFoto(c *fiber.Ctx) error {
 	// Validasi ukuran file (max 1MB)
 	maxSize := int64(1 * 1024 * 1024)
 	if fileHeader.Size > maxSize {
+		utils.ObserveUpload("foto", "rejected", fileHeader.Size)
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
 			"message": "File size exceeds 1MB limit",
@@ -61,6 +62,7 @@ func (s *fileUploadService) UploadFoto(c *fiber.Ctx) error {
 
 	contentType := fileHeader.Header.Get("Content-Type")
 	if !allowedTypes[contentType] {
+		utils.ObserveUpload("foto", "rejected", fileHeader.Size)
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
 			"message": "File type not allowed. Only JPEG, JPG, and PNG are allowed",
@@ -75,6 +77,7 @@ func (s *fileUploadService) UploadFoto(c *fiber.Ctx) error {
 
 	// Create directory if not exists
 	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
+		utils.ObserveUpload("foto", "error", fileHeader.Size)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"message": "Failed to create upload directory",
@@ -84,6 +87,7 @@ func (s *fileUploadService) UploadFoto(c *fiber.Ctx) error {
 
 	// Save file
 	if err := c.SaveFile(fileHeader, filePath); err != nil {
+		utils.ObserveUpload("foto", "error", fileHeader.Size)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"message": "Failed to save file",
@@ -99,8 +103,9 @@ func (s *fileUploadService) UploadFoto(c *fiber.Ctx) error {
 		FileSize:     fileHeader.Size,
 	}
 
-	if err := s.repo.Create(fileModel); err != nil {
+	if err := s.repo.Create(c.UserContext(), fileModel); err != nil {
 		os.Remove(filePath)
+		utils.ObserveUpload("foto", "error", fileHeader.Size)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"message": "Failed to save file metadata",
@@ -108,6 +113,7 @@ func (s *fileUploadService) UploadFoto(c *fiber.Ctx) error {
 		})
 	}
 
+	utils.ObserveUpload("foto", "success", fileHeader.Size)
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"success": true,
 		"message": "Foto uploaded successfully",
@@ -128,6 +134,7 @@ func (s *fileUploadService) UploadSertifikat(c *fiber.Ctx) error {
 	// Validasi ukuran file (max 2MB)
 	maxSize := int64(2 * 1024 * 1024)
 	if fileHeader.Size > maxSize {
+		utils.ObserveUpload("sertifikat", "rejected", fileHeader.Size)
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
 			"message": "File size exceeds 2MB limit",
@@ -137,6 +144,7 @@ func (s *fileUploadService) UploadSertifikat(c *fiber.Ctx) error {
 	// Validasi tipe file
 	contentType := fileHeader.Header.Get("Content-Type")
 	if contentType != "application/pdf" {
+		utils.ObserveUpload("sertifikat", "rejected", fileHeader.Size)
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
 			"message": "File type not allowed. Only PDF is allowed",
@@ -151,6 +159,7 @@ func (s *fileUploadService) UploadSertifikat(c *fiber.Ctx) error {
 
 	// Create directory if not exists
 	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
+		utils.ObserveUpload("sertifikat", "error", fileHeader.Size)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"message": "Failed to create upload directory",
@@ -160,6 +169,7 @@ func (s *fileUploadService) UploadSertifikat(c *fiber.Ctx) error {
 
 	// Save file
 	if err := c.SaveFile(fileHeader, filePath); err != nil {
+		utils.ObserveUpload("sertifikat", "error", fileHeader.Size)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"message": "Failed to save file",
@@ -175,8 +185,9 @@ func (s *fileUploadService) UploadSertifikat(c *fiber.Ctx) error {
 		FileSize:     fileHeader.Size,
 	}
 
-	if err := s.repo.Create(fileModel); err != nil {
+	if err := s.repo.Create(c.UserContext(), fileModel); err != nil {
 		os.Remove(filePath)
+		utils.ObserveUpload("sertifikat", "error", fileHeader.Size)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"message": "Failed to save file metadata",
@@ -184,6 +195,7 @@ func (s *fileUploadService) UploadSertifikat(c *fiber.Ctx) error {
 		})
 	}
 
+	utils.ObserveUpload("sertifikat", "success", fileHeader.Size)
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"success": true,
 		"message": "Sertifikat uploaded successfully",
@@ -192,7 +204,7 @@ func (s *fileUploadService) UploadSertifikat(c *fiber.Ctx) error {
 }
 
 func (s *fileUploadService) GetAllFiles(c *fiber.Ctx) error {
-	files, err := s.repo.FindAll()
+	files, err := s.repo.FindAll(c.UserContext())
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
@@ -230,7 +242,7 @@ func (s *fileUploadService) GetFileByID(c *fiber.Ctx) error {
 		})
 	}
 
-	file, err := s.repo.FindByID(fileID)
+	file, err := s.repo.FindByID(c.UserContext(), fileID)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"success": false,
@@ -262,7 +274,7 @@ func (s *fileUploadService) DeleteFile(c *fiber.Ctx) error {
 		})
 	}
 
-	file, err := s.repo.FindByID(fileID)
+	file, err := s.repo.FindByID(c.UserContext(), fileID)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"success": false,
@@ -272,11 +284,11 @@ func (s *fileUploadService) DeleteFile(c *fiber.Ctx) error {
 
 	// Delete file from storage
 	if err := os.Remove(file.FilePath); err != nil {
-		fmt.Println("Warning: Failed to delete file from storage:", err)
+		requestLogger(c).Warn("Failed to delete file from storage", "error", err)
 	}
 
 	// Delete from database
-	if err := s.repo.Delete(fileID); err != nil {
+	if err := s.repo.Delete(c.UserContext(), fileID); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"message": "Failed to delete file",
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"time"
+
+	"hello-fiber/app/model"
+	"hello-fiber/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// currentImpersonatorID mengambil ID admin jika request memakai token impersonasi
+func currentImpersonatorID(c *fiber.Ctx) (bson.ObjectID, bool) {
+	actor, _ := c.Locals("impersonator_id").(string)
+	if actor == "" {
+		return bson.NilObjectID, false
+	}
+	id, err := bson.ObjectIDFromHex(actor)
+	return id, err == nil
+}
+
+// IsImpersonating bernilai true jika request dilakukan admin atas nama user lain
+func IsImpersonating(c *fiber.Ctx) bool {
+	_, ok := currentImpersonatorID(c)
+	return ok
+}
+
+// ImpersonationBanner adalah informasi impersonasi yang disisipkan ke setiap response
+func ImpersonationBanner(c *fiber.Ctx) fiber.Map {
+	banner := fiber.Map{
+		"active":      true,
+		"user_id":     c.Locals("user_id"),
+		"user_email":  c.Locals("email"),
+		"admin_id":    c.Locals("impersonator_id"),
+		"admin_email": c.Locals("impersonator_email"),
+	}
+	if expiresAt, ok := c.Locals("token_expires_at").(time.Time); ok {
+		banner["expires_at"] = expiresAt
+	}
+	return banner
+}
+
+// RecordImpersonatedRequest mencatat request yang dilakukan admin selama impersonasi
+func RecordImpersonatedRequest(c *fiber.Ctx, status int) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return
+	}
+	email, _ := c.Locals("email").(string)
+	recordSecurityEvent(c, model.SecurityEventImpersonatedRequest, &userID, email, bson.M{
+		"method": c.Method(),
+		"path":   c.Path(),
+		"status": status,
+	})
+}
+
+// ImpersonateUserService (admin) menerbitkan access token berumur pendek atas nama user lain.
+// Token membawa claim act berisi admin sehingga semua aksinya tercatat atas nama admin tersebut.
+func ImpersonateUserService(c *fiber.Ctx) error {
+	if IsImpersonating(c) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Tidak dapat memulai impersonasi dari sesi impersonasi"})
+	}
+
+	admin, err := currentUser(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "User ID tidak valid"})
+	}
+
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "User ID tidak valid"})
+	}
+	if id == admin.ID {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Tidak dapat melakukan impersonasi terhadap akun sendiri"})
+	}
+
+	target, err := userRepo.GetUserByID(c.UserContext(), id)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "User tidak ditemukan", "error": err.Error()})
+	}
+	if target.EffectiveStatus(time.Now()) != model.UserStatusActive {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Tidak dapat melakukan impersonasi terhadap akun yang tidak aktif"})
+	}
+
+	// Admin tidak boleh mendapatkan akses yang lebih tinggi dari role-nya sendiri lewat impersonasi
+	role, err := roleRepo.GetRoleByID(c.UserContext(), target.RoleID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mengambil role user", "error": err.Error()})
+	}
+	if !canGrantRole(c, role) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Tidak dapat melakukan impersonasi terhadap user dengan role lebih tinggi"})
+	}
+
+	// amr admin ikut dibawa supaya kebijakan REQUIRE_ADMIN_2FA tetap berlaku
+	var amr []string
+	if mfa, _ := c.Locals("mfa").(bool); mfa {
+		amr = append(amr, utils.AMROTP)
+	}
+	token, expiresAt, err := utils.GenerateImpersonationJWT(*target, *admin, amr...)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal membuat token impersonasi", "error": err.Error()})
+	}
+
+	recordSecurityEvent(c, model.SecurityEventImpersonationStarted, &target.ID, target.Email, bson.M{"expires_at": expiresAt})
+
+	return c.JSON(fiber.Map{
+		"success":    true,
+		"message":    "Token impersonasi berhasil dibuat",
+		"token":      token,
+		"token_type": "Bearer",
+		"expires_in": int64(time.Until(expiresAt).Seconds()),
+		"user":       toUserResponse(target),
+	})
+}
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"hello-fiber/app/model"
+	"hello-fiber/app/repository"
+	"hello-fiber/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var invitationRepo = repository.NewInvitationRepositoryMongo()
+
+// defaultRegistrationRole mengambil role untuk registrasi publik (nama role dari DEFAULT_ROLE, default "user")
+func defaultRegistrationRole(ctx context.Context) (*model.Role, error) {
+	name := utils.GetEnv("DEFAULT_ROLE", "user")
+	role, err := roleRepo.GetRoleByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, errors.New("role default '" + name + "' tidak ditemukan")
+	}
+	return role, nil
+}
+
+// sendInvitationEmail mengirim link undangan ke email yang diundang
+func sendInvitationEmail(inv *model.Invitation, token string) error {
+	link := utils.GetEnv("INVITATION_URL", "http://localhost:3000/register") + "?invite_token=" + token
+	body := "Halo,\n\n" +
+		"Anda diundang untuk membuat akun. Buka link berikut dan daftar menggunakan email " + inv.Email + ":\n\n" +
+		link + "\n\n" +
+		"Undangan ini berlaku sampai " + inv.ExpiresAt.Format("02 Jan 2006 15:04 MST") + "."
+	return utils.GetMailer().Send(inv.Email, "Undangan Pembuatan Akun", body)
+}
+
+// CreateInvitationService (admin) membuat undangan registrasi dengan role tertentu
+func CreateInvitationService(c *fiber.Ctx) error {
+	var req model.CreateInvitationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Request body tidak valid", "error": err.Error()})
+	}
+
+	if req.Email == "" || !isValidEmail(req.Email) {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Format email tidak valid"})
+	}
+
+	var role *model.Role
+	var err error
+	if req.RoleID == bson.NilObjectID {
+		role, err = defaultRegistrationRole(c.UserContext())
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mengambil role default", "error": err.Error()})
+		}
+	} else if role, err = roleRepo.GetRoleByID(c.UserContext(), req.RoleID); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Role ID tidak valid", "error": err.Error()})
+	}
+
+	if !canGrantRole(c, role) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Tidak boleh mengundang dengan role yang memiliki akses lebih tinggi dari role Anda"})
+	}
+	req.RoleID = role.ID
+
+	registered, err := emailRegistered(c.UserContext(), strings.ToLower(strings.TrimSpace(req.Email)))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal validasi email", "error": err.Error()})
+	}
+	if registered {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Email sudah terdaftar"})
+	}
+
+	adminID, _ := currentUserID(c)
+	token, tokenHash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal membuat token undangan", "error": err.Error()})
+	}
+
+	inv := &model.Invitation{
+		Email:     req.Email,
+		RoleID:    req.RoleID,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(utils.GetEnvDuration("INVITATION_TTL", 7*24*time.Hour)),
+		CreatedBy: adminID,
+	}
+	if err := invitationRepo.CreateInvitation(c.UserContext(), inv); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal menyimpan undangan", "error": err.Error()})
+	}
+
+	if err := sendInvitationEmail(inv, token); err != nil {
+		requestLogger(c).Error("Error sending invitation email", "error", err)
+	}
+
+	return c.Status(201).JSON(fiber.Map{"success": true, "message": "Undangan berhasil dikirim", "data": inv})
+}
+
+// InviteAlumniService (admin) mengundang satu atau banyak alumni memakai email di data alumni.
+// Akun yang dibuat dari undangan ini otomatis tertaut ke alumni tersebut.
+func InviteAlumniService(c *fiber.Ctx) error {
+	var req model.InviteAlumniRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Request body tidak valid", "error": err.Error()})
+	}
+
+	if len(req.AlumniIDs) == 0 {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "alumni_ids wajib diisi"})
+	}
+	maxBatch := utils.GetEnvInt("INVITATION_MAX_BATCH", 100)
+	if len(req.AlumniIDs) > maxBatch {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Jumlah alumni melebihi batas undangan sekaligus"})
+	}
+
+	var role *model.Role
+	var err error
+	if req.RoleID == bson.NilObjectID {
+		role, err = defaultRegistrationRole(c.UserContext())
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mengambil role default", "error": err.Error()})
+		}
+	} else if role, err = roleRepo.GetRoleByID(c.UserContext(), req.RoleID); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Role ID tidak valid", "error": err.Error()})
+	}
+
+	if !canGrantRole(c, role) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Tidak boleh mengundang dengan role yang memiliki akses lebih tinggi dari role Anda"})
+	}
+
+	adminID, _ := currentUserID(c)
+	ttl := utils.GetEnvDuration("INVITATION_TTL", 7*24*time.Hour)
+
+	results := make([]model.InviteAlumniResult, 0, len(req.AlumniIDs))
+	sent := 0
+	seen := make(map[bson.ObjectID]bool)
+	for _, alumniID := range req.AlumniIDs {
+		result := model.InviteAlumniResult{AlumniID: alumniID}
+		if seen[alumniID] {
+			result.Message = "Alumni duplikat dalam request"
+			results = append(results, result)
+			continue
+		}
+		seen[alumniID] = true
+
+		alumni, err := alumniRepo.GetAlumniByID(c.UserContext(), alumniID)
+		if err != nil {
+			result.Message = "Alumni tidak ditemukan"
+			results = append(results, result)
+			continue
+		}
+		email := strings.ToLower(strings.TrimSpace(alumni.Email))
+		result.Email = email
+
+		if email == "" || !isValidEmail(email) {
+			result.Message = "Email alumni tidak valid"
+			results = append(results, result)
+			continue
+		}
+		if linked, err := userRepo.GetUserByAlumniID(c.UserContext(), alumniID); err != nil || linked != nil {
+			result.Message = "Alumni sudah memiliki akun"
+			results = append(results, result)
+			continue
+		}
+		registered, err := emailRegistered(c.UserContext(), email)
+		if err != nil {
+			result.Message = "Gagal validasi email"
+			results = append(results, result)
+			continue
+		}
+		if registered {
+			result.Message = "Email sudah terdaftar"
+			results = append(results, result)
+			continue
+		}
+		if pending, err := invitationRepo.HasPendingInvitation(c.UserContext(), email); err != nil || pending {
+			result.Message = "Masih ada undangan aktif untuk email ini, gunakan kirim ulang"
+			results = append(results, result)
+			continue
+		}
+
+		token, tokenHash, err := utils.GenerateOpaqueToken()
+		if err != nil {
+			result.Message = "Gagal membuat token undangan"
+			results = append(results, result)
+			continue
+		}
+
+		id := alumniID
+		inv := &model.Invitation{
+			Email:     email,
+			RoleID:    role.ID,
+			AlumniID:  &id,
+			TokenHash: tokenHash,
+			ExpiresAt: time.Now().Add(ttl),
+			CreatedBy: adminID,
+		}
+		if err := invitationRepo.CreateInvitation(c.UserContext(), inv); err != nil {
+			result.Message = "Gagal menyimpan undangan"
+			results = append(results, result)
+			continue
+		}
+
+		if err := sendInvitationEmail(inv, token); err != nil {
+			requestLogger(c).Error("Error sending invitation email", "error", err)
+		}
+
+		result.Success = true
+		result.Message = "Undangan berhasil dikirim"
+		result.InvitationID = &inv.ID
+		results = append(results, result)
+		sent++
+	}
+
+	return c.Status(207).JSON(fiber.Map{
+		"success": sent > 0,
+		"message": "Undangan diproses",
+		"sent":    sent,
+		"failed":  len(results) - sent,
+		"data":    results,
+	})
+}
+
+// GetInvitationsService (admin) menampilkan daftar undangan, bisa difilter ?status=pending|accepted|expired
+func GetInvitationsService(c *fiber.Ctx) error {
+	page := int64(1)
+	limit := int64(10)
+
+	if p := c.Query("page"); p != "" {
+		page = int64(c.QueryInt("page", 1))
+	}
+	if l := c.Query("limit"); l != "" {
+		limit = int64(c.QueryInt("limit", 10))
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", model.InvitationPending, model.InvitationAccepted, model.InvitationExpired:
+	default:
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Status harus pending, accepted, atau expired"})
+	}
+
+	invitations, total, err := invitationRepo.GetInvitations(c.UserContext(), status, page, limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mengambil data undangan", "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Data undangan berhasil diambil",
+		"data":    invitations,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// ResendInvitationService (admin) mengirim ulang undangan yang belum diterima dengan token dan masa berlaku baru
+func ResendInvitationService(c *fiber.Ctx) error {
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "ID undangan tidak valid"})
+	}
+
+	existing, err := invitationRepo.GetInvitationByID(c.UserContext(), id)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Undangan tidak ditemukan"})
+	}
+	if existing.Status == model.InvitationAccepted {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Undangan sudah diterima"})
+	}
+
+	if role, err := roleRepo.GetRoleByID(c.UserContext(), existing.RoleID); err != nil || !canGrantRole(c, role) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Tidak boleh mengirim ulang undangan dengan role yang memiliki akses lebih tinggi dari role Anda"})
+	}
+
+	token, tokenHash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal membuat token undangan", "error": err.Error()})
+	}
+
+	inv, err := invitationRepo.RotateInvitationToken(c.UserContext(), id, tokenHash, time.Now().Add(utils.GetEnvDuration("INVITATION_TTL", 7*24*time.Hour)))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": err.Error()})
+	}
+
+	if err := sendInvitationEmail(inv, token); err != nil {
+		requestLogger(c).Error("Error sending invitation email", "error", err)
+	}
+
+	return c.JSON(fiber.Map{"success": true, "message": "Undangan berhasil dikirim ulang", "data": inv})
+}
@@ -0,0 +1,24 @@
+package service
+
+import (
+	"log/slog"
+
+	"hello-fiber/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// requestLogger mengembalikan logger yang sudah membawa request_id, trace_id, dan user_id request saat ini
+func requestLogger(c *fiber.Ctx) *slog.Logger {
+	logger := slog.Default()
+	if requestID, ok := c.Locals("request_id").(string); ok && requestID != "" {
+		logger = logger.With("request_id", requestID)
+	}
+	if traceID := utils.TraceID(c.UserContext()); traceID != "" {
+		logger = logger.With("trace_id", traceID)
+	}
+	if userID, ok := c.Locals("user_id").(string); ok && userID != "" {
+		logger = logger.With("user_id", userID)
+	}
+	return logger
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"hello-fiber/app/model"
+	"hello-fiber/app/repository"
+	"hello-fiber/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Proteksi brute-force login. Kegagalan dihitung per akun (email) dan per IP di MongoDB
+// supaya berlaku di semua replica. Setelah melewati batas, key dikunci dengan durasi
+// yang berlipat dua setiap kegagalan berikutnya (LOGIN_LOCKOUT_BASE s/d LOGIN_LOCKOUT_MAX).
+// IP diambil dari c.IP(); header proxy hanya dipercaya dari TRUSTED_PROXIES (lihat config.NewApp).
+var loginAttemptRepo = repository.NewLoginAttemptRepositoryMongo()
+
+type loginKey struct {
+	key         string
+	maxAttempts int
+}
+
+func loginKeys(email, ip string) []loginKey {
+	return []loginKey{
+		{key: "email:" + email, maxAttempts: utils.GetEnvInt("LOGIN_MAX_ATTEMPTS", 5)},
+		{key: "ip:" + ip, maxAttempts: utils.GetEnvInt("LOGIN_IP_MAX_ATTEMPTS", 20)},
+	}
+}
+
+// lockoutDuration menghitung durasi kunci dengan exponential backoff
+func lockoutDuration(excess int) time.Duration {
+	base := utils.GetEnvDuration("LOGIN_LOCKOUT_BASE", time.Minute)
+	maxLock := utils.GetEnvDuration("LOGIN_LOCKOUT_MAX", time.Hour)
+	if excess > 20 {
+		return maxLock
+	}
+	d := time.Duration(float64(base) * math.Pow(2, float64(excess)))
+	if d > maxLock {
+		return maxLock
+	}
+	return d
+}
+
+// loginLockRemaining mengembalikan sisa waktu kunci terlama dari email dan IP (0 jika tidak terkunci)
+func loginLockRemaining(ctx context.Context, email, ip string) (time.Duration, error) {
+	var remaining time.Duration
+	for _, k := range loginKeys(email, ip) {
+		attempt, err := loginAttemptRepo.GetAttempt(ctx, k.key)
+		if err != nil {
+			return 0, err
+		}
+		if attempt != nil && attempt.LockedUntil != nil {
+			if d := time.Until(*attempt.LockedUntil); d > remaining {
+				remaining = d
+			}
+		}
+	}
+	return remaining, nil
+}
+
+// recordLoginFailure mencatat kegagalan login dan mengunci key yang melewati batas
+func recordLoginFailure(c *fiber.Ctx, email string) error {
+	window := utils.GetEnvDuration("LOGIN_ATTEMPT_WINDOW", 15*time.Minute)
+	for _, k := range loginKeys(email, c.IP()) {
+		attempt, err := loginAttemptRepo.IncrementFailure(c.UserContext(), k.key, window)
+		if err != nil {
+			return err
+		}
+		if attempt.Failures < k.maxAttempts {
+			continue
+		}
+
+		lockedUntil := time.Now().Add(lockoutDuration(attempt.Failures - k.maxAttempts))
+		if err := loginAttemptRepo.Lock(c.UserContext(), k.key, lockedUntil, lockedUntil.Add(window)); err != nil {
+			return err
+		}
+		if err := loginAttemptRepo.CreateLockoutEvent(c.UserContext(), &model.LockoutEvent{
+			Key:         k.key,
+			Email:       email,
+			IP:          c.IP(),
+			UserAgent:   c.Get(fiber.HeaderUserAgent),
+			Failures:    attempt.Failures,
+			LockedUntil: lockedUntil,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resetLoginFailures menghapus hitungan kegagalan akun setelah login berhasil.
+// Hitungan IP sengaja tidak direset supaya penyerang tidak bisa mereset dengan akun miliknya sendiri.
+func resetLoginFailures(ctx context.Context, email string) error {
+	return loginAttemptRepo.ResetAttempts(ctx, "email:"+email)
+}
+
+func tooManyLoginAttempts(c *fiber.Ctx, retryAfter time.Duration) error {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"success":     false,
+		"message":     "Terlalu banyak percobaan login gagal, coba lagi nanti",
+		"retry_after": seconds,
+	})
+}
+
+// UnlockUserService (admin) membuka kunci login sebuah akun
+func UnlockUserService(c *fiber.Ctx) error {
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "User ID tidak valid"})
+	}
+
+	user, err := userRepo.GetUserByID(c.UserContext(), id)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "User tidak ditemukan", "error": err.Error()})
+	}
+
+	if err := resetLoginFailures(c.UserContext(), user.Email); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal membuka kunci akun", "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "message": "Kunci login akun berhasil dibuka"})
+}
+
+// GetLockoutEventsService (admin) menampilkan riwayat lockout untuk memantau serangan
+func GetLockoutEventsService(c *fiber.Ctx) error {
+	page := int64(c.QueryInt("page", 1))
+	limit := int64(c.QueryInt("limit", 20))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	events, total, err := loginAttemptRepo.GetLockoutEvents(c.UserContext(), page, limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mengambil data lockout", "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Data lockout berhasil diambil",
+		"data":    events,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
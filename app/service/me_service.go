@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"hello-fiber/app/model"
+	"hello-fiber/app/repository"
+	"hello-fiber/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// meResponse menyusun profil user beserta data alumni yang tertaut (jika ada)
+func meResponse(ctx context.Context, user *model.User) *model.MeResponse {
+	resp := &model.MeResponse{User: toUserResponse(user)}
+	if !isObjectIDEmpty(user.AlumniID) {
+		if alumni, err := alumniRepo.GetAlumniByID(ctx, *user.AlumniID); err == nil {
+			resp.Alumni = alumni
+		}
+	}
+	return resp
+}
+
+// GetMeService menampilkan profil user yang sedang login
+func GetMeService(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "User tidak ditemukan"})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "message": "Profil berhasil diambil", "data": meResponse(c.UserContext(), user)})
+}
+
+// UpdateMeService mengubah username/email user yang sedang login.
+// Email baru harus diverifikasi ulang sebelum akun dianggap terverifikasi lagi.
+func UpdateMeService(c *fiber.Ctx) error {
+	var req model.UpdateMeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Request body tidak valid", "error": err.Error()})
+	}
+
+	if req.Username == "" && req.Email == "" {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Minimal ada satu field yang harus diupdate (username atau email)"})
+	}
+	if req.Username != "" && !isValidUsername(req.Username) {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Username harus 3-50 karakter, hanya alphanumeric dan underscore"})
+	}
+	if req.Email != "" && !isValidEmail(req.Email) {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Format email tidak valid"})
+	}
+
+	user, err := currentUser(c)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "User tidak ditemukan"})
+	}
+
+	if req.Username != "" && req.Username != user.Username {
+		existingUser, err := userRepo.GetUserByUsername(c.UserContext(), req.Username)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal validasi username", "error": err.Error()})
+		}
+		if existingUser != nil && existingUser.ID != user.ID {
+			return c.Status(400).JSON(fiber.Map{"success": false, "message": "Username sudah terdaftar"})
+		}
+	}
+
+	newEmail := strings.ToLower(strings.TrimSpace(req.Email))
+	emailChanged := newEmail != "" && newEmail != user.Email
+	if emailChanged {
+		registered, err := emailRegistered(c.UserContext(), newEmail)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal validasi email", "error": err.Error()})
+		}
+		if registered {
+			return c.Status(400).JSON(fiber.Map{"success": false, "message": "Email sudah terdaftar"})
+		}
+	}
+
+	if err := userRepo.UpdateProfile(c.UserContext(), user.ID, req, emailChanged); err != nil {
+		if errors.Is(err, repository.ErrUserExists) {
+			return c.Status(400).JSON(fiber.Map{"success": false, "message": "Email atau username sudah terdaftar"})
+		}
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Gagal update profil", "error": err.Error()})
+	}
+
+	updated, err := userRepo.GetUserByID(c.UserContext(), user.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mengambil profil", "error": err.Error()})
+	}
+
+	message := "Profil berhasil diupdate"
+	if emailChanged {
+		// Token lama masih membawa status verifikasi email lama, cabut supaya email baru
+		// harus diverifikasi dulu sebelum bisa mengakses endpoint yang mewajibkannya
+		if err := revokeAllUserTokens(c.UserContext(), user.ID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Email diganti tetapi gagal mencabut sesi lama", "error": err.Error()})
+		}
+		recordSecurityEvent(c, model.SecurityEventTokensRevoked, &user.ID, updated.Email, bson.M{"scope": "all", "reason": "email_changed"})
+		if err := sendVerificationEmail(c.UserContext(), updated); err != nil {
+			requestLogger(c).Error("Error sending verification email", "error", err)
+		}
+		message = "Profil berhasil diupdate, silakan cek email baru untuk verifikasi lalu login kembali"
+	}
+
+	return c.JSON(fiber.Map{"success": true, "message": message, "data": meResponse(c.UserContext(), updated)})
+}
+
+// ChangeMyPasswordService mengganti password user yang sedang login.
+// Password lama wajib benar, dan semua sesi dicabut setelah password diganti.
+func ChangeMyPasswordService(c *fiber.Ctx) error {
+	var req model.ChangePasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Request body tidak valid", "error": err.Error()})
+	}
+
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Password lama dan password baru harus diisi"})
+	}
+
+	user, err := currentUser(c)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "User tidak ditemukan"})
+	}
+
+	if !utils.CheckPassword(req.CurrentPassword, user.Password) {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Password lama salah"})
+	}
+
+	if req.NewPassword == req.CurrentPassword {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Password baru tidak boleh sama dengan password lama"})
+	}
+	if violations := validatePassword(req.NewPassword, user); len(violations) > 0 {
+		return passwordPolicyError(c, violations)
+	}
+
+	if err := changePassword(c.UserContext(), user.ID, req.NewPassword); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mengganti password", "error": err.Error()})
+	}
+	recordSecurityEvent(c, model.SecurityEventPasswordChanged, &user.ID, user.Email, bson.M{"method": "self"})
+
+	if err := revokeAllUserTokens(c.UserContext(), user.ID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Password diganti tetapi gagal mencabut sesi lama", "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "message": "Password berhasil diganti, silakan login kembali"})
+}
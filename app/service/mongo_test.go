@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"hello-fiber/app/model"
+	"hello-fiber/app/repository"
+	"hello-fiber/database"
+	"hello-fiber/utils"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var testMongoOnce sync.Once
+
+// requireTestMongo menghubungkan ke database test (MONGO_URI + MONGO_TEST_DB_NAME, default
+// hello_fiber_test) lalu menyiapkan index, migrasi, dan JWT_SECRET test (jika belum diisi).
+// Test dilewati jika MONGO_URI tidak diisi.
+func requireTestMongo(t *testing.T) {
+	t.Helper()
+	if os.Getenv("MONGO_URI") == "" {
+		t.Skip("MONGO_URI tidak diisi, test ini butuh MongoDB")
+	}
+	testMongoOnce.Do(func() {
+		os.Setenv("MONGO_DB_NAME", utils.GetEnv("MONGO_TEST_DB_NAME", "hello_fiber_test"))
+		if os.Getenv("JWT_SECRET") == "" {
+			os.Setenv("JWT_SECRET", "service-test-secret-yang-cukup-panjang")
+		}
+		database.ConnectMongoDB()
+		if err := repository.EnsureIndexes(); err != nil {
+			t.Fatal(err)
+		}
+		if err := repository.RunMigrations(); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// createTestUser membuat user sementara dengan role DEFAULT_ROLE, dihapus setelah test selesai
+func createTestUser(t *testing.T) *model.User {
+	t.Helper()
+	ctx := context.Background()
+	role, err := defaultRegistrationRole(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := "test_" + bson.NewObjectID().Hex()
+	id, err := userRepo.Register(ctx, model.RegisterRequest{
+		Username: name,
+		Email:    name + "@example.com",
+		Password: "Test-Pass123",
+		RoleID:   role.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = userRepo.DeleteUser(context.Background(), id) })
+
+	user, err := userRepo.GetUserByID(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
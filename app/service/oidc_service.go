@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"hello-fiber/app/model"
+	"hello-fiber/app/repository"
+	"hello-fiber/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var oidcStateRepo = repository.NewOIDCStateRepositoryMongo()
+
+// Error resolveOIDCUser yang pesannya aman ditampilkan ke client. Error lain (mis. dari
+// repository) hanya dicatat di log dan client menerima pesan umum.
+var (
+	errOIDCEmailUnverified = errors.New("IdP tidak mengirim email yang terverifikasi")
+	errOIDCLinkedElsewhere = errors.New("akun dengan email ini sudah tertaut ke identitas OIDC lain")
+	errOIDCEmailRegistered = errors.New("akun dengan email ini sudah terdaftar, login dengan password")
+	errOIDCAdminLink       = errors.New("akun admin tidak dapat ditautkan otomatis, login dengan password")
+	errOIDCLinkFailed      = errors.New("akun tidak dapat ditautkan ke identitas OIDC ini")
+	errOIDCNotRegistered   = errors.New("akun belum terdaftar, hubungi admin")
+)
+
+// oidcErrorMessage mengembalikan pesan untuk client dari error resolveOIDCUser
+func oidcErrorMessage(err error) string {
+	for _, public := range []error{errOIDCEmailUnverified, errOIDCLinkedElsewhere, errOIDCEmailRegistered, errOIDCAdminLink, errOIDCLinkFailed, errOIDCNotRegistered} {
+		if errors.Is(err, public) {
+			return public.Error()
+		}
+	}
+	return "Login OIDC gagal"
+}
+
+// oidcProvisionRole mengambil role untuk user yang dibuat otomatis dari OIDC
+// (OIDC_DEFAULT_ROLE, default sama dengan DEFAULT_ROLE)
+func oidcProvisionRole(ctx context.Context) (*model.Role, error) {
+	name := utils.GetEnv("OIDC_DEFAULT_ROLE", "")
+	if name == "" {
+		return defaultRegistrationRole(ctx)
+	}
+	role, err := roleRepo.GetRoleByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, errors.New("role OIDC default '" + name + "' tidak ditemukan")
+	}
+	return role, nil
+}
+
+// oidcUsername membuat username unik dari preferred_username atau bagian lokal email
+func oidcUsername(ctx context.Context, claims *utils.OIDCIDTokenClaims) (string, error) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base = strings.SplitN(claims.Email, "@", 2)[0]
+	}
+
+	var b strings.Builder
+	for _, r := range base {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			b.WriteRune(r)
+		} else if r == '.' || r == '-' {
+			b.WriteRune('_')
+		}
+	}
+	base = b.String()
+	if len(base) > 40 {
+		base = base[:40]
+	}
+	for len(base) < 3 {
+		base += "_"
+	}
+
+	candidate := base
+	for i := 0; i < 5; i++ {
+		existing, err := userRepo.GetUserByUsername(ctx, candidate)
+		if err != nil {
+			return "", err
+		}
+		if existing == nil {
+			return candidate, nil
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+		if err != nil {
+			return "", err
+		}
+		candidate = fmt.Sprintf("%s_%06d", base, n.Int64())
+	}
+	return "", errors.New("gagal membuat username unik")
+}
+
+// resolveOIDCUser mencari user untuk identitas IdP: lewat subject yang sudah tertaut, lalu
+// lewat email terverifikasi jika OIDC_LINK_BY_EMAIL=true, dan terakhir membuat user baru jika
+// OIDC_AUTO_PROVISION=true. Akun dengan permission "*" tidak pernah ditautkan otomatis.
+func resolveOIDCUser(ctx context.Context, issuer string, claims *utils.OIDCIDTokenClaims) (*model.User, int, error) {
+	user, err := userRepo.GetUserByOIDCSubject(ctx, issuer, claims.Subject)
+	if err != nil {
+		return nil, 500, err
+	}
+	if user != nil {
+		return user, 0, nil
+	}
+
+	// Email dari IdP hanya dipercaya jika IdP menyatakan sudah terverifikasi
+	if claims.Email == "" || claims.EmailVerified == nil || !*claims.EmailVerified {
+		return nil, 403, errOIDCEmailUnverified
+	}
+	email := strings.ToLower(strings.TrimSpace(claims.Email))
+
+	existing, err := userRepo.GetUserByEmail(ctx, email)
+	if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+		return nil, 500, err
+	}
+	if existing != nil {
+		if existing.OIDCSubject != "" {
+			return nil, 409, errOIDCLinkedElsewhere
+		}
+		if utils.GetEnv("OIDC_LINK_BY_EMAIL", "false") != "true" {
+			return nil, 409, errOIDCEmailRegistered
+		}
+		role, err := getRole(ctx, existing.RoleID)
+		if err != nil {
+			return nil, 500, err
+		}
+		if role.HasAllPermissions() {
+			return nil, 409, errOIDCAdminLink
+		}
+		if err := userRepo.LinkOIDCIdentity(ctx, existing.ID, issuer, claims.Subject); err != nil {
+			return nil, 409, fmt.Errorf("%w: %w", errOIDCLinkFailed, err)
+		}
+		existing.OIDCIssuer, existing.OIDCSubject, existing.EmailVerified = issuer, claims.Subject, true
+		return existing, 0, nil
+	}
+
+	if utils.GetEnv("OIDC_AUTO_PROVISION", "false") != "true" {
+		return nil, 403, errOIDCNotRegistered
+	}
+
+	role, err := oidcProvisionRole(ctx)
+	if err != nil {
+		return nil, 500, err
+	}
+	username, err := oidcUsername(ctx, claims)
+	if err != nil {
+		return nil, 500, err
+	}
+	user, err = userRepo.CreateOIDCUser(ctx, username, email, role.ID, issuer, claims.Subject)
+	if err != nil {
+		return nil, 500, err
+	}
+	return user, 0, nil
+}
+
+// OIDCLoginService memulai login OIDC: menyimpan state/nonce/PKCE lalu redirect ke IdP.
+// Gunakan ?redirect=false untuk mendapatkan URL-nya dalam JSON (mis. untuk SPA).
+func OIDCLoginService(c *fiber.Ctx) error {
+	cfg, err := utils.GetOIDCConfig()
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Login OIDC tidak tersedia", "error": err.Error()})
+	}
+
+	state, stateHash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal membuat state OIDC", "error": err.Error()})
+	}
+	nonce, _, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal membuat nonce OIDC", "error": err.Error()})
+	}
+	verifier, _, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal membuat code verifier", "error": err.Error()})
+	}
+
+	authURL, err := utils.OIDCAuthURL(c.UserContext(), cfg, state, nonce, verifier)
+	if err != nil {
+		requestLogger(c).Error("Error reading OIDC discovery", "error", err)
+		return c.Status(502).JSON(fiber.Map{"success": false, "message": "Gagal menghubungi IdP"})
+	}
+
+	if err := oidcStateRepo.CreateState(c.UserContext(), &model.OIDCLoginState{
+		StateHash:    stateHash,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(utils.GetEnvDuration("OIDC_STATE_TTL", 10*time.Minute)),
+	}); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal menyimpan state OIDC", "error": err.Error()})
+	}
+
+	if c.Query("redirect") == "false" {
+		return c.JSON(fiber.Map{"success": true, "message": "Buka URL berikut untuk login", "authorization_url": authURL})
+	}
+	return c.Redirect(authURL, fiber.StatusFound)
+}
+
+// OIDCCallbackService menerima redirect dari IdP, menukar code (dengan PKCE verifier),
+// memetakan identitas IdP ke user, lalu menerbitkan token aplikasi seperti LoginService
+func OIDCCallbackService(c *fiber.Ctx) error {
+	cfg, err := utils.GetOIDCConfig()
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Login OIDC tidak tersedia", "error": err.Error()})
+	}
+
+	if idpErr := c.Query("error"); idpErr != "" {
+		requestLogger(c).Warn("OIDC login rejected by IdP", "idp_error", idpErr, "idp_error_description", c.Query("error_description"))
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Login dibatalkan atau ditolak IdP"})
+	}
+
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "code dan state harus diisi"})
+	}
+
+	loginState, err := oidcStateRepo.ConsumeState(c.UserContext(), utils.HashOpaqueToken(state))
+	if err != nil {
+		if errors.Is(err, repository.ErrOIDCStateInvalid) {
+			return c.Status(400).JSON(fiber.Map{"success": false, "message": "State OIDC tidak valid atau kedaluwarsa, silakan ulangi login"})
+		}
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal memproses state OIDC", "error": err.Error()})
+	}
+
+	claims, err := utils.OIDCExchangeCode(c.UserContext(), cfg, code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		requestLogger(c).Warn("OIDC code exchange failed", "error", err)
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Gagal memverifikasi login dari IdP"})
+	}
+
+	user, status, err := resolveOIDCUser(c.UserContext(), cfg.Issuer, claims)
+	if err != nil {
+		if status >= 500 {
+			requestLogger(c).Error("Error resolving OIDC user", "error", err)
+			return c.Status(status).JSON(fiber.Map{"success": false, "message": "Login OIDC gagal"})
+		}
+		requestLogger(c).Warn("OIDC login rejected", "status", status, "error", err)
+		return c.Status(status).JSON(fiber.Map{"success": false, "message": "Login OIDC gagal", "error": oidcErrorMessage(err)})
+	}
+
+	if err := accountStatusError(c, user); err != nil {
+		return err
+	}
+
+	amr := []string{utils.AMROIDC}
+	idpMFA := false
+	for _, m := range claims.AMR {
+		if m == "mfa" || m == utils.AMROTP {
+			idpMFA = true
+		}
+	}
+	if idpMFA {
+		amr = append(amr, utils.AMROTP)
+	}
+
+	// User yang mengaktifkan 2FA di aplikasi tetap diminta kode jika IdP tidak melakukan MFA
+	if user.TOTPEnabled && !idpMFA {
+		challenge, err := utils.GenerateTwoFactorChallengeToken(*user)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal membuat challenge 2FA", "error": err.Error()})
+		}
+		return c.JSON(fiber.Map{
+			"success":             true,
+			"message":             "Masukkan kode 2FA untuk melanjutkan login",
+			"two_factor_required": true,
+			"challenge_token":     challenge,
+		})
+	}
+
+	tokens, err := issueTokens(c, user, bson.NilObjectID, amr)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal membuat token", "error": err.Error()})
+	}
+	utils.ObserveLogin(true)
+
+	return c.JSON(fiber.Map{
+		"success":       true,
+		"message":       "Login berhasil",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"token_type":    tokens.TokenType,
+		"expires_in":    tokens.ExpiresIn,
+		"user":          toUserResponse(user),
+	})
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"hello-fiber/utils/oidctest"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// setupOIDCTest menyiapkan IdP tiruan dan app dengan route login/callback OIDC.
+// State OIDC disimpan di MongoDB, jadi test dilewati jika MONGO_URI tidak diisi.
+func setupOIDCTest(t *testing.T) (*oidctest.Provider, *fiber.App) {
+	t.Helper()
+	requireTestMongo(t)
+
+	idp, err := oidctest.New("alumni-api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(idp.Close)
+
+	t.Setenv("OIDC_ISSUER", idp.Issuer())
+	t.Setenv("OIDC_CLIENT_ID", "alumni-api")
+	t.Setenv("OIDC_REDIRECT_URL", "http://localhost:3000/api/auth/oidc/callback")
+	t.Setenv("OIDC_AUTO_PROVISION", "true")
+
+	app := fiber.New()
+	app.Get("/login", OIDCLoginService)
+	app.Get("/callback", OIDCCallbackService)
+	return idp, app
+}
+
+func oidcTestRequest(t *testing.T, app *fiber.App, target string) (int, fiber.Map) {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest("GET", target, nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body := fiber.Map{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, body
+}
+
+func TestOIDCCallbackRejectsStateReplay(t *testing.T) {
+	idp, app := setupOIDCTest(t)
+
+	status, body := oidcTestRequest(t, app, "/login?redirect=false")
+	if status != fiber.StatusOK {
+		t.Fatalf("login OIDC: status %d, %v", status, body)
+	}
+	authURL, _ := body["authorization_url"].(string)
+
+	email := "oidc-" + bson.NewObjectID().Hex() + "@example.com"
+	code, state, err := idp.Authorize(authURL, map[string]interface{}{
+		"sub":            "replay-" + bson.NewObjectID().Hex(),
+		"email":          email,
+		"email_verified": true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if user, err := userRepo.GetUserByEmail(t.Context(), email); err == nil {
+			_ = userRepo.DeleteUser(t.Context(), user.ID)
+		}
+	})
+
+	callback := "/callback?" + url.Values{"code": {code}, "state": {state}}.Encode()
+	if status, body := oidcTestRequest(t, app, callback); status != fiber.StatusOK {
+		t.Fatalf("callback pertama: status %d, %v", status, body)
+	}
+	if status, body := oidcTestRequest(t, app, callback); status != fiber.StatusBadRequest {
+		t.Fatalf("state yang sama dipakai ulang: status %d, want 400 (%v)", status, body)
+	}
+}
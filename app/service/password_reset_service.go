@@ -0,0 +1,138 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"hello-fiber/app/model"
+	"hello-fiber/app/repository"
+	"hello-fiber/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var passwordResetRepo = repository.NewPasswordResetRepositoryMongo()
+
+// ForgotPasswordService mengirim link reset password ke email user.
+// Response selalu sama baik email terdaftar atau tidak supaya tidak bisa dipakai untuk enumerasi akun.
+// Permintaan dibatasi per IP (PASSWORD_RESET_IP_MAX per PASSWORD_RESET_IP_WINDOW) dan email ke
+// satu akun paling sering dikirim sekali per PASSWORD_RESET_RESEND_INTERVAL.
+func ForgotPasswordService(c *fiber.Ctx) error {
+	var req model.ForgotPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Request body tidak valid", "error": err.Error()})
+	}
+
+	if req.Email == "" || !isValidEmail(req.Email) {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Format email tidak valid"})
+	}
+
+	// Batas IP dihitung sebelum mencari user supaya tidak membedakan email terdaftar atau tidak
+	window := utils.GetEnvDuration("PASSWORD_RESET_IP_WINDOW", time.Hour)
+	attempt, err := loginAttemptRepo.IncrementFailure(c.UserContext(), "reset_ip:"+c.IP(), window)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal memproses permintaan reset", "error": err.Error()})
+	}
+	if attempt.Failures > utils.GetEnvInt("PASSWORD_RESET_IP_MAX", 10) {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(time.Until(attempt.ExpiresAt).Seconds()))))
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"success": false, "message": "Terlalu banyak permintaan reset password, coba lagi nanti"})
+	}
+
+	response := fiber.Map{"success": true, "message": "Jika email terdaftar, link reset password telah dikirim"}
+
+	user, err := userRepo.GetUserByEmail(c.UserContext(), strings.ToLower(strings.TrimSpace(req.Email)))
+	if err != nil {
+		return c.JSON(response)
+	}
+
+	// Cooldown per akun: permintaan berulang tetap mendapat response sukses tanpa mengirim email
+	allowed, err := userRepo.ClaimPasswordResetSend(c.UserContext(), user.ID, utils.GetEnvDuration("PASSWORD_RESET_RESEND_INTERVAL", time.Minute))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal memproses permintaan reset", "error": err.Error()})
+	}
+	if !allowed {
+		return c.JSON(response)
+	}
+
+	token, tokenHash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal membuat token reset", "error": err.Error()})
+	}
+
+	ttl := utils.GetEnvDuration("PASSWORD_RESET_TTL", 30*time.Minute)
+	if err := passwordResetRepo.CreateResetToken(c.UserContext(), &model.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(ttl),
+	}); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal menyimpan token reset", "error": err.Error()})
+	}
+
+	link := utils.GetEnv("PASSWORD_RESET_URL", "http://localhost:3000/reset-password") + "?token=" + token
+	body := "Halo " + user.Username + ",\n\n" +
+		"Kami menerima permintaan reset password untuk akun Anda. Buka link berikut untuk membuat password baru:\n\n" +
+		link + "\n\n" +
+		"Link ini hanya bisa dipakai sekali dan berlaku selama " + ttl.String() + ".\n" +
+		"Abaikan email ini jika Anda tidak merasa meminta reset password."
+
+	if err := utils.GetMailer().Send(user.Email, "Reset Password", body); err != nil {
+		requestLogger(c).Error("Error sending password reset email", "error", err)
+	}
+
+	return c.JSON(response)
+}
+
+// ResetPasswordService mengganti password memakai token reset, lalu mencabut semua sesi user
+func ResetPasswordService(c *fiber.Ctx) error {
+	var req model.ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Request body tidak valid", "error": err.Error()})
+	}
+
+	if req.Token == "" || req.Password == "" {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Token dan password harus diisi"})
+	}
+
+	tokenHash := utils.HashOpaqueToken(req.Token)
+
+	// Password divalidasi (termasuk riwayat) sebelum token dipakai, supaya token tidak hangus
+	// hanya karena password baru ditolak kebijakan
+	pending, err := passwordResetRepo.GetActiveResetToken(c.UserContext(), tokenHash)
+	if err != nil {
+		if errors.Is(err, repository.ErrResetTokenInvalid) {
+			return c.Status(400).JSON(fiber.Map{"success": false, "message": "Token reset password tidak valid atau kedaluwarsa"})
+		}
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal memproses token reset", "error": err.Error()})
+	}
+
+	user, err := userRepo.GetUserByID(c.UserContext(), pending.UserID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "User tidak ditemukan"})
+	}
+	if violations := validatePassword(req.Password, user); len(violations) > 0 {
+		return passwordPolicyError(c, violations)
+	}
+
+	resetToken, err := passwordResetRepo.ConsumeResetToken(c.UserContext(), tokenHash)
+	if err != nil {
+		if errors.Is(err, repository.ErrResetTokenInvalid) {
+			return c.Status(400).JSON(fiber.Map{"success": false, "message": "Token reset password tidak valid atau kedaluwarsa"})
+		}
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal memproses token reset", "error": err.Error()})
+	}
+
+	if err := changePassword(c.UserContext(), resetToken.UserID, req.Password); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mengganti password", "error": err.Error()})
+	}
+	recordSecurityEvent(c, model.SecurityEventPasswordChanged, &resetToken.UserID, "", bson.M{"method": "reset"})
+
+	if err := revokeAllUserTokens(c.UserContext(), resetToken.UserID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Password diganti tetapi gagal mencabut sesi lama", "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "message": "Password berhasil direset, silakan login kembali"})
+}
@@ -12,10 +12,17 @@ import (
 
 var pekerjaanRepo = repository.NewPekerjaanAlumniRepositoryMongo()
 
+// ownerAlumniID mengembalikan alumni_id pemilik jika request lolos lewat PekerjaanOwnerMiddlewareMongo
+// (bukan lewat permission penuh)
+func ownerAlumniID(c *fiber.Ctx) (bson.ObjectID, bool) {
+	id, ok := c.Locals("owner_alumni_id").(bson.ObjectID)
+	return id, ok
+}
+
 // GetAllPekerjaanAlumniService mengambil semua pekerjaan alumni (filter sederhana dengan query is_delete/search opsional)
 func GetAllPekerjaanAlumniService(c *fiber.Ctx) error {
 	// optional search query handled by repo if implemented; here we return all non-deleted
-	data, err := pekerjaanRepo.GetAllPekerjaanAlumni()
+	data, err := pekerjaanRepo.GetAllPekerjaanAlumni(c.UserContext())
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mengambil data pekerjaan alumni", "error": err.Error()})
 	}
@@ -34,7 +41,7 @@ func GetPekerjaanAlumniByIDService(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"success": false, "message": "ID format tidak valid"})
 	}
 
-	item, err := pekerjaanRepo.GetPekerjaanAlumniByID(id)
+	item, err := pekerjaanRepo.GetPekerjaanAlumniByID(c.UserContext(), id)
 	if err != nil {
 		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Pekerjaan alumni tidak ditemukan", "error": err.Error()})
 	}
@@ -53,7 +60,7 @@ func GetPekerjaanAlumniByAlumniIDService(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"success": false, "message": "alumni_id format tidak valid"})
 	}
 
-	list, err := pekerjaanRepo.GetPekerjaanAlumniByAlumniID(alumniID)
+	list, err := pekerjaanRepo.GetPekerjaanAlumniByAlumniID(c.UserContext(), alumniID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mengambil data pekerjaan alumni dari alumni_id", "error": err.Error()})
 	}
@@ -67,6 +74,14 @@ func CreatePekerjaanAlumniService(c *fiber.Ctx) error {
         return c.Status(400).JSON(fiber.Map{"success": false, "message": "Request body tidak valid", "error": err.Error()})
     }
 
+	// Alumni yang mengakses lewat kepemilikan hanya boleh membuat pekerjaan untuk dirinya sendiri
+	if ownerID, ok := ownerAlumniID(c); ok {
+		if req.AlumniID != bson.NilObjectID && req.AlumniID != ownerID {
+			return c.Status(403).JSON(fiber.Map{"success": false, "message": "Anda hanya boleh menambahkan pekerjaan untuk data alumni Anda sendiri"})
+		}
+		req.AlumniID = ownerID
+	}
+
     if err := validatePekerjaanInput(req); err != nil {
         return c.Status(400).JSON(fiber.Map{"success": false, "message": err.Error()})
     }
@@ -75,7 +90,7 @@ func CreatePekerjaanAlumniService(c *fiber.Ctx) error {
 		req.StatusPekerjaan = "aktif"
 	}
 
-	id, err := pekerjaanRepo.CreatePekerjaanAlumni(req)
+	id, err := pekerjaanRepo.CreatePekerjaanAlumni(c.UserContext(), req)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal menambahkan pekerjaan alumni", "error": err.Error()})
 	}
@@ -99,11 +114,16 @@ func UpdatePekerjaanAlumniService(c *fiber.Ctx) error {
         return c.Status(400).JSON(fiber.Map{"success": false, "message": "Request body tidak valid", "error": err.Error()})
     }
 
+	// Pemilik tidak boleh memindahkan pekerjaannya ke alumni lain
+	if ownerID, ok := ownerAlumniID(c); ok && req.AlumniID != nil && *req.AlumniID != ownerID {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "alumni_id tidak boleh diubah ke alumni lain"})
+	}
+
     if err := validatePekerjaanUpdateInput(req); err != nil {
         return c.Status(400).JSON(fiber.Map{"success": false, "message": err.Error()})
     }
 
-    err = pekerjaanRepo.UpdatePekerjaanAlumni(id, req)
+    err = pekerjaanRepo.UpdatePekerjaanAlumni(c.UserContext(), id, req)
     if err != nil {
         return c.Status(404).JSON(fiber.Map{"success": false, "message": "Gagal mengupdate pekerjaan alumni", "error": err.Error()})
     }
@@ -138,7 +158,7 @@ func SoftDeletePekerjaanAlumniService(c *fiber.Ctx) error {
 	}
 
 	isDelete := body.IsDelete == "hapus"
-	if err := pekerjaanRepo.SoftDeletePekerjaanAlumni(id, isDelete); err != nil {
+	if err := pekerjaanRepo.SoftDeletePekerjaanAlumni(c.UserContext(), id, isDelete); err != nil {
 		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Gagal mengubah status is_delete", "error": err.Error()})
 	}
 	return c.JSON(fiber.Map{"success": true, "message": "Status is_delete berhasil diupdate"})
@@ -146,7 +166,7 @@ func SoftDeletePekerjaanAlumniService(c *fiber.Ctx) error {
 
 // GetTrashedPekerjaanAlumniService mengambil semua yang is_delete == true
 func GetTrashedPekerjaanAlumniService(c *fiber.Ctx) error {
-	data, err := pekerjaanRepo.GetTrashedPekerjaanAlumni()
+	data, err := pekerjaanRepo.GetTrashedPekerjaanAlumni(c.UserContext())
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mengambil trashed items", "error": err.Error()})
 	}
@@ -165,7 +185,7 @@ func HardDeleteTrashedPekerjaanAlumniService(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"success": false, "message": "ID format tidak valid"})
 	}
 
-	item, err := pekerjaanRepo.HardDeleteTrashedPekerjaanAlumni(id)
+	item, err := pekerjaanRepo.HardDeleteTrashedPekerjaanAlumni(c.UserContext(), id)
 	if err != nil {
 		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Gagal menghapus permanen atau item tidak ditemukan", "error": err.Error()})
 	}
@@ -184,7 +204,7 @@ func RestoreTrashedPekerjaanAlumniService(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"success": false, "message": "ID format tidak valid"})
 	}
 
-	item, err := pekerjaanRepo.RestoreTrashedPekerjaanAlumni(id)
+	item, err := pekerjaanRepo.RestoreTrashedPekerjaanAlumni(c.UserContext(), id)
 	if err != nil {
 		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Gagal merestore item atau item tidak ditemukan", "error": err.Error()})
 	}
@@ -203,7 +223,7 @@ func DeletePekerjaanAlumniService(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"success": false, "message": "ID format tidak valid"})
 	}
 
-	if err := pekerjaanRepo.SoftDeletePekerjaanAlumni(id, true); err != nil {
+	if err := pekerjaanRepo.SoftDeletePekerjaanAlumni(c.UserContext(), id, true); err != nil {
 		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Gagal menghapus pekerjaan alumni", "error": err.Error()})
 	}
 	return c.JSON(fiber.Map{"success": true, "message": "Pekerjaan alumni berhasil dihapus"})
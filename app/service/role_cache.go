@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"hello-fiber/app/model"
+	"hello-fiber/app/repository"
+	"hello-fiber/utils"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// roleCache menyimpan semua role di memori supaya middleware otorisasi tidak perlu query
+// collection roles di setiap request. Cache dimuat saat startup lalu diperbarui lewat change
+// stream MongoDB; pada server standalone (tanpa change stream) cache dimuat ulang setiap
+// ROLE_CACHE_POLL_INTERVAL (default 30 detik). Role yang dikembalikan dipakai bersama
+// oleh banyak request sehingga tidak boleh diubah.
+//
+// ID role yang tidak ada di database juga diingat selama roleMissTTL, supaya token dengan
+// role_id tidak dikenal tidak memicu query ke MongoDB di setiap request.
+type roleCache struct {
+	mu      sync.RWMutex
+	roles   map[bson.ObjectID]*model.Role
+	missing map[bson.ObjectID]time.Time
+	ready   bool
+}
+
+const roleMissTTL = 30 * time.Second
+
+var roleCacheStore = &roleCache{roles: map[bson.ObjectID]*model.Role{}, missing: map[bson.ObjectID]time.Time{}}
+
+// reload mengganti isi cache dengan semua role dari database
+func (rc *roleCache) reload() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	list, err := roleRepo.GetAllRoles(ctx)
+	if err != nil {
+		return err
+	}
+
+	roles := make(map[bson.ObjectID]*model.Role, len(list))
+	for i := range list {
+		roles[list[i].ID] = &list[i]
+	}
+
+	rc.mu.Lock()
+	rc.roles = roles
+	rc.missing = map[bson.ObjectID]time.Time{}
+	rc.ready = true
+	rc.mu.Unlock()
+	return nil
+}
+
+func (rc *roleCache) get(id bson.ObjectID) (*model.Role, bool) {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	if !rc.ready {
+		return nil, false
+	}
+	role, ok := rc.roles[id]
+	return role, ok
+}
+
+// isMissing mengecek apakah ID role baru saja dicari dan tidak ditemukan
+func (rc *roleCache) isMissing(id bson.ObjectID) bool {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	until, ok := rc.missing[id]
+	return ok && time.Now().Before(until)
+}
+
+func (rc *roleCache) setMissing(id bson.ObjectID) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	now := time.Now()
+	for k, until := range rc.missing {
+		if now.After(until) {
+			delete(rc.missing, k)
+		}
+	}
+	rc.missing[id] = now.Add(roleMissTTL)
+}
+
+func (rc *roleCache) set(role *model.Role) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	delete(rc.missing, role.ID)
+	if rc.ready {
+		rc.roles[role.ID] = role
+	}
+}
+
+func (rc *roleCache) remove(id bson.ObjectID) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	delete(rc.roles, id)
+}
+
+// StartRoleCache memuat semua role ke cache lalu menjalankan sinkronisasi di background
+func StartRoleCache() error {
+	if err := roleCacheStore.reload(); err != nil {
+		return err
+	}
+	go watchRoles()
+	return nil
+}
+
+// watchRoles memuat ulang cache setiap ada perubahan pada collection roles.
+// Jika change stream tidak didukung, beralih ke polling.
+func watchRoles() {
+	for {
+		stream, err := roleRepo.WatchRoles(context.Background())
+		if err != nil {
+			slog.Warn("Change stream roles tidak tersedia, role cache memakai polling", "error", err)
+			pollRoles()
+			return
+		}
+
+		// Perubahan yang terjadi sebelum stream terbuka tidak terkirim, jadi muat ulang sekali
+		if err := roleCacheStore.reload(); err != nil {
+			slog.Error("Error reloading role cache", "error", err)
+		}
+		for stream.Next(context.Background()) {
+			if err := roleCacheStore.reload(); err != nil {
+				slog.Error("Error reloading role cache", "error", err)
+			}
+		}
+
+		slog.Warn("Change stream roles terputus, mencoba lagi", "error", stream.Err())
+		_ = stream.Close(context.Background())
+		time.Sleep(5 * time.Second)
+	}
+}
+
+func pollRoles() {
+	ticker := time.NewTicker(utils.GetEnvDuration("ROLE_CACHE_POLL_INTERVAL", 30*time.Second))
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := roleCacheStore.reload(); err != nil {
+			slog.Error("Error reloading role cache", "error", err)
+		}
+	}
+}
+
+// getRole mengambil role dari cache. Cache miss (mis. role baru dari replica lain yang belum
+// tersinkron) dibaca dari database lalu disimpan ke cache; ID yang tidak ada diingat sebentar.
+func getRole(ctx context.Context, id bson.ObjectID) (*model.Role, error) {
+	if role, ok := roleCacheStore.get(id); ok {
+		return role, nil
+	}
+	if roleCacheStore.isMissing(id) {
+		return nil, repository.ErrRoleNotFound
+	}
+	role, err := roleRepo.GetRoleByID(ctx, id)
+	if errors.Is(err, repository.ErrRoleNotFound) {
+		roleCacheStore.setMissing(id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	roleCacheStore.set(role)
+	return role, nil
+}
+
+// refreshCachedRole memperbarui satu role di cache setelah diubah lewat API, supaya
+// perubahan langsung berlaku di instance ini tanpa menunggu change stream atau polling
+func refreshCachedRole(ctx context.Context, id bson.ObjectID) {
+	role, err := roleRepo.GetRoleByID(ctx, id)
+	if err != nil {
+		roleCacheStore.remove(id)
+		return
+	}
+	roleCacheStore.set(role)
+}
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"hello-fiber/app/model"
+	"hello-fiber/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func isValidRoleName(name string) bool {
+	name = strings.TrimSpace(name)
+	return len(name) >= 2 && len(name) <= 50
+}
+
+// invalidPermissions mengembalikan permission yang tidak dikenali
+func invalidPermissions(permissions []string) []string {
+	var invalid []string
+	for _, p := range permissions {
+		if !model.IsValidPermission(p) {
+			invalid = append(invalid, p)
+		}
+	}
+	return invalid
+}
+
+// isProtectedRoleName mengecek role yang dipakai registrasi publik (DEFAULT_ROLE) dan
+// provisioning OIDC (OIDC_DEFAULT_ROLE); role ini tidak boleh diganti nama atau dihapus
+func isProtectedRoleName(name string) bool {
+	if name == utils.GetEnv("DEFAULT_ROLE", "user") {
+		return true
+	}
+	oidcRole := utils.GetEnv("OIDC_DEFAULT_ROLE", "")
+	return oidcRole != "" && name == oidcRole
+}
+
+// otherSuperAdminRoles mengambil role selain exclude yang memiliki permission "*"
+func otherSuperAdminRoles(ctx context.Context, exclude bson.ObjectID) ([]model.Role, error) {
+	roles, err := roleRepo.GetAllRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var result []model.Role
+	for _, r := range roles {
+		if r.ID != exclude && r.HasAllPermissions() {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
+// countUsersInRoles menjumlahkan user yang memakai salah satu role
+func countUsersInRoles(ctx context.Context, roles []model.Role) (int64, error) {
+	var total int64
+	for _, r := range roles {
+		count, err := userRepo.CountUsersByRole(ctx, r.ID)
+		if err != nil {
+			return 0, err
+		}
+		total += count
+	}
+	return total, nil
+}
+
+// GetAllRolesService mengambil semua role
+func GetAllRolesService(c *fiber.Ctx) error {
+	roles, err := roleRepo.GetAllRoles(c.UserContext())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mengambil data role", "error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"success": true, "message": "Data role berhasil diambil", "data": roles})
+}
+
+// GetRoleByIDService mengambil role berdasarkan ID beserta jumlah user yang memakainya
+func GetRoleByIDService(c *fiber.Ctx) error {
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Role ID tidak valid"})
+	}
+
+	role, err := roleRepo.GetRoleByID(c.UserContext(), id)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Role tidak ditemukan", "error": err.Error()})
+	}
+
+	userCount, err := userRepo.CountUsersByRole(c.UserContext(), id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal menghitung user", "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "message": "Data role berhasil diambil", "data": role, "user_count": userCount})
+}
+
+// GetPermissionsService menampilkan daftar permission yang bisa dipakai di role
+func GetPermissionsService(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"success": true, "message": "Daftar permission berhasil diambil", "data": model.AllPermissions})
+}
+
+// CreateRoleService membuat role baru
+func CreateRoleService(c *fiber.Ctx) error {
+	var req model.CreateRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Request body tidak valid", "error": err.Error()})
+	}
+
+	if !isValidRoleName(req.Role) {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Nama role harus 2-50 karakter"})
+	}
+
+	if invalid := invalidPermissions(req.Permissions); len(invalid) > 0 {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Permission tidak dikenal", "invalid_permissions": invalid})
+	}
+	if !canGrantRole(c, &model.Role{Permissions: req.Permissions}) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Tidak boleh memberikan permission yang tidak Anda miliki"})
+	}
+
+	existing, err := roleRepo.GetRoleByName(c.UserContext(), strings.TrimSpace(req.Role))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal validasi nama role", "error": err.Error()})
+	}
+	if existing != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Nama role sudah dipakai"})
+	}
+
+	id, err := roleRepo.CreateRole(c.UserContext(), req)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal membuat role", "error": err.Error()})
+	}
+	refreshCachedRole(c.UserContext(), id)
+
+	return c.Status(201).JSON(fiber.Map{"success": true, "message": "Role berhasil dibuat", "id": id.Hex()})
+}
+
+// UpdateRoleService mengganti nama dan/atau permission role
+func UpdateRoleService(c *fiber.Ctx) error {
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Role ID tidak valid"})
+	}
+
+	var req model.UpdateRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Request body tidak valid", "error": err.Error()})
+	}
+
+	if req.Role == "" && req.Permissions == nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Minimal ada satu field yang harus diupdate (role atau permissions)"})
+	}
+
+	role, err := roleRepo.GetRoleByID(c.UserContext(), id)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Role tidak ditemukan", "error": err.Error()})
+	}
+	if !canGrantRole(c, role) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Tidak boleh mengubah role dengan permission lebih tinggi dari role Anda"})
+	}
+
+	if req.Role != "" && strings.TrimSpace(req.Role) != role.Role && isProtectedRoleName(role.Role) {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Role default registrasi tidak boleh diganti nama"})
+	}
+
+	if req.Role != "" {
+		if !isValidRoleName(req.Role) {
+			return c.Status(400).JSON(fiber.Map{"success": false, "message": "Nama role harus 2-50 karakter"})
+		}
+		existing, err := roleRepo.GetRoleByName(c.UserContext(), strings.TrimSpace(req.Role))
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal validasi nama role", "error": err.Error()})
+		}
+		if existing != nil && existing.ID != id {
+			return c.Status(400).JSON(fiber.Map{"success": false, "message": "Nama role sudah dipakai"})
+		}
+	}
+
+	if req.Permissions != nil {
+		if invalid := invalidPermissions(*req.Permissions); len(invalid) > 0 {
+			return c.Status(400).JSON(fiber.Map{"success": false, "message": "Permission tidak dikenal", "invalid_permissions": invalid})
+		}
+		updated := &model.Role{Permissions: *req.Permissions}
+		if !canGrantRole(c, updated) {
+			return c.Status(403).JSON(fiber.Map{"success": false, "message": "Tidak boleh memberikan permission yang tidak Anda miliki"})
+		}
+		if role.HasAllPermissions() && !updated.HasAllPermissions() {
+			others, err := otherSuperAdminRoles(c.UserContext(), id)
+			if err != nil {
+				return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal validasi role", "error": err.Error()})
+			}
+			if len(others) == 0 {
+				return c.Status(409).JSON(fiber.Map{"success": false, "message": "Permission \"*\" tidak boleh dihapus dari role super admin terakhir"})
+			}
+		}
+	}
+
+	if err := roleRepo.UpdateRole(c.UserContext(), id, req); err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Gagal update role", "error": err.Error()})
+	}
+	refreshCachedRole(c.UserContext(), id)
+
+	return c.JSON(fiber.Map{"success": true, "message": "Role berhasil diupdate"})
+}
+
+// DeleteRoleService menghapus role yang sudah tidak dipakai user manapun
+func DeleteRoleService(c *fiber.Ctx) error {
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Role ID tidak valid"})
+	}
+
+	role, err := roleRepo.GetRoleByID(c.UserContext(), id)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Role tidak ditemukan", "error": err.Error()})
+	}
+	if isProtectedRoleName(role.Role) {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Role default registrasi tidak boleh dihapus"})
+	}
+
+	userCount, err := userRepo.CountUsersByRole(c.UserContext(), id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal menghitung user", "error": err.Error()})
+	}
+	if userCount > 0 {
+		return c.Status(409).JSON(fiber.Map{
+			"success":    false,
+			"message":    "Role masih dipakai user, pindahkan user ke role lain terlebih dahulu",
+			"user_count": userCount,
+		})
+	}
+
+	if err := roleRepo.DeleteRole(c.UserContext(), id); err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Gagal delete role", "error": err.Error()})
+	}
+	roleCacheStore.remove(id)
+
+	return c.JSON(fiber.Map{"success": true, "message": "Role berhasil dihapus"})
+}
+
+// ReassignRoleService memindahkan semua user dari role :id ke role tujuan
+func ReassignRoleService(c *fiber.Ctx) error {
+	fromID, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Role ID tidak valid"})
+	}
+
+	var req model.ReassignRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Request body tidak valid", "error": err.Error()})
+	}
+
+	if req.ToRoleID == bson.NilObjectID {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "to_role_id harus diisi"})
+	}
+	if req.ToRoleID == fromID {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Role asal dan tujuan tidak boleh sama"})
+	}
+
+	fromRole, err := roleRepo.GetRoleByID(c.UserContext(), fromID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Role asal tidak ditemukan", "error": err.Error()})
+	}
+	toRole, err := roleRepo.GetRoleByID(c.UserContext(), req.ToRoleID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "Role tujuan tidak ditemukan", "error": err.Error()})
+	}
+	if !canGrantRole(c, fromRole) || !canGrantRole(c, toRole) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Tidak boleh memindahkan user dari/ke role dengan permission lebih tinggi dari role Anda"})
+	}
+
+	// Pastikan masih ada user dengan permission "*" setelah pemindahan
+	if fromRole.HasAllPermissions() && !toRole.HasAllPermissions() {
+		others, err := otherSuperAdminRoles(c.UserContext(), fromID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal validasi role", "error": err.Error()})
+		}
+		admins, err := countUsersInRoles(c.UserContext(), others)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal menghitung user", "error": err.Error()})
+		}
+		if admins == 0 {
+			return c.Status(409).JSON(fiber.Map{"success": false, "message": "Pemindahan ini membuat tidak ada user dengan permission \"*\""})
+		}
+	}
+
+	movedIDs, err := userRepo.ReassignRole(c.UserContext(), fromID, req.ToRoleID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal memindahkan user", "error": err.Error()})
+	}
+	moved := len(movedIDs)
+	if moved > 0 {
+		recordSecurityEvent(c, model.SecurityEventRoleChanged, nil, "", bson.M{"from_role_id": fromID.Hex(), "to_role_id": req.ToRoleID.Hex(), "moved": moved})
+	}
+
+	// Token lama masih membawa role_id asal, jadi harus dicabut supaya role baru langsung berlaku
+	for _, userID := range movedIDs {
+		if err := revokeAllUserTokens(c.UserContext(), userID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "User dipindahkan tetapi gagal mencabut token", "error": err.Error(), "moved": moved})
+		}
+	}
+
+	return c.JSON(fiber.Map{"success": true, "message": "User berhasil dipindahkan ke role baru", "moved": moved})
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"time"
+
+	"hello-fiber/app/model"
+	"hello-fiber/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var securityEventRepo = repository.NewSecurityEventRepositoryMongo()
+
+// recordSecurityEvent mencatat kejadian keamanan dari request saat ini. Kegagalan pencatatan
+// hanya di-log supaya tidak menggagalkan aksi utamanya.
+func recordSecurityEvent(c *fiber.Ctx, eventType string, userID *bson.ObjectID, email string, details bson.M) {
+	event := &model.SecurityEvent{
+		UserID:    userID,
+		Email:     email,
+		Type:      eventType,
+		IP:        c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		Details:   details,
+	}
+	// Aksi admin terhadap user lain dicatat beserta pelakunya. Selama impersonasi,
+	// pelakunya selalu admin yang sebenarnya.
+	if actorID, ok := currentImpersonatorID(c); ok {
+		event.ActorID = &actorID
+	} else if actorID, err := currentUserID(c); err == nil && (userID == nil || *userID != actorID) {
+		event.ActorID = &actorID
+	}
+
+	if err := securityEventRepo.CreateSecurityEvent(c.UserContext(), event); err != nil {
+		requestLogger(c).Error("Error recording security event", "error", err)
+	}
+}
+
+// parseEventTime menerima RFC3339 atau tanggal (YYYY-MM-DD). Untuk batas akhir, tanggal
+// dianggap inklusif sehingga dikonversi ke awal hari berikutnya.
+func parseEventTime(value string, endOfRange bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	if endOfRange {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// querySecurityEvents membaca filter type, from, to, page, dan limit dari query string
+func querySecurityEvents(c *fiber.Ctx, filter model.SecurityEventFilter) error {
+	var err error
+	filter.Type = c.Query("type")
+	if filter.From, err = parseEventTime(c.Query("from"), false); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Format from tidak valid, gunakan RFC3339 atau YYYY-MM-DD"})
+	}
+	if filter.To, err = parseEventTime(c.Query("to"), true); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Format to tidak valid, gunakan RFC3339 atau YYYY-MM-DD"})
+	}
+
+	page := int64(c.QueryInt("page", 1))
+	limit := int64(c.QueryInt("limit", 20))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	events, total, err := securityEventRepo.GetSecurityEvents(c.UserContext(), filter, page, limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mengambil security event", "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Data security event berhasil diambil",
+		"data":    events,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// GetSecurityEventsService (admin) menampilkan security event, bisa difilter dengan
+// user_id, type, from, dan to
+func GetSecurityEventsService(c *fiber.Ctx) error {
+	var filter model.SecurityEventFilter
+	if userID := c.Query("user_id"); userID != "" {
+		id, err := bson.ObjectIDFromHex(userID)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"success": false, "message": "User ID tidak valid"})
+		}
+		filter.UserID = &id
+	}
+	return querySecurityEvents(c, filter)
+}
+
+// GetMySecurityEventsService menampilkan riwayat login dan aktivitas keamanan akun sendiri
+func GetMySecurityEventsService(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "User ID tidak valid"})
+	}
+	return querySecurityEvents(c, model.SecurityEventFilter{UserID: &userID})
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"hello-fiber/app/model"
+	"hello-fiber/app/repository"
+	"hello-fiber/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var sessionRepo = repository.NewSessionRepositoryMongo()
+
+// currentSessionID mengambil ID session dari claim sid access token yang sedang dipakai
+func currentSessionID(c *fiber.Ctx) (bson.ObjectID, error) {
+	sid, _ := c.Locals("sid").(string)
+	return bson.ObjectIDFromHex(sid)
+}
+
+// revokeSession mencabut session beserta family refresh token-nya, sehingga access token
+// dengan sid tersebut langsung ditolak dan refresh token-nya tidak bisa dipakai lagi.
+// Kepemilikan session dicek lebih dulu supaya user tidak bisa mencabut session orang lain.
+func revokeSession(ctx context.Context, sessionID, userID bson.ObjectID) error {
+	if err := sessionRepo.RevokeSession(ctx, sessionID, userID); err != nil {
+		return err
+	}
+	if err := refreshTokenRepo.RevokeFamily(ctx, sessionID, userID); err != nil {
+		return err
+	}
+	revocations.setSession(sessionID, revocationEntry{revoked: true, validUntil: time.Now().Add(utils.GetAccessTokenTTL())})
+	return nil
+}
+
+// listSessions mengambil session aktif user dan menandai session yang sedang dipakai
+func listSessions(ctx context.Context, userID, current bson.ObjectID) ([]model.Session, error) {
+	sessions, err := sessionRepo.GetActiveSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = !current.IsZero() && sessions[i].ID == current
+	}
+	return sessions, nil
+}
+
+// GetMySessionsService menampilkan daftar perangkat/browser tempat user sedang login
+func GetMySessionsService(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "User ID tidak valid"})
+	}
+	current, _ := currentSessionID(c)
+
+	sessions, err := listSessions(c.UserContext(), userID, current)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mengambil data session", "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "message": "Data session berhasil diambil", "data": sessions})
+}
+
+// RevokeMySessionService mencabut salah satu session milik user (mis. HP yang hilang)
+func RevokeMySessionService(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "User ID tidak valid"})
+	}
+
+	sessionID, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Session ID tidak valid"})
+	}
+
+	if err := revokeSession(c.UserContext(), sessionID, userID); err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return c.Status(404).JSON(fiber.Map{"success": false, "message": "Session tidak ditemukan"})
+		}
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mencabut session", "error": err.Error()})
+	}
+	recordSecurityEvent(c, model.SecurityEventTokensRevoked, &userID, "", bson.M{"scope": "session", "session_id": sessionID.Hex()})
+
+	return c.JSON(fiber.Map{"success": true, "message": "Session berhasil dicabut"})
+}
+
+// RevokeOtherSessionsService mencabut semua session user kecuali session yang sedang dipakai
+func RevokeOtherSessionsService(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "User ID tidak valid"})
+	}
+	current, _ := currentSessionID(c)
+
+	ids, err := sessionRepo.RevokeAllForUser(c.UserContext(), userID, current)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mencabut session", "error": err.Error()})
+	}
+	for _, id := range ids {
+		if err := refreshTokenRepo.RevokeFamily(c.UserContext(), id, userID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mencabut refresh token", "error": err.Error()})
+		}
+		revocations.setSession(id, revocationEntry{revoked: true, validUntil: time.Now().Add(utils.GetAccessTokenTTL())})
+	}
+	if len(ids) > 0 {
+		recordSecurityEvent(c, model.SecurityEventTokensRevoked, &userID, "", bson.M{"scope": "other_sessions", "revoked": len(ids)})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "message": "Session lain berhasil dicabut", "revoked": len(ids)})
+}
+
+// GetUserSessionsService (admin) menampilkan session aktif milik user tertentu
+func GetUserSessionsService(c *fiber.Ctx) error {
+	userID, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "User ID tidak valid"})
+	}
+
+	if _, err := userRepo.GetUserByID(c.UserContext(), userID); err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "User tidak ditemukan", "error": err.Error()})
+	}
+
+	sessions, err := listSessions(c.UserContext(), userID, bson.NilObjectID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mengambil data session", "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "message": "Data session berhasil diambil", "data": sessions})
+}
+
+// RevokeUserSessionService (admin) mencabut satu session milik user tertentu
+func RevokeUserSessionService(c *fiber.Ctx) error {
+	userID, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "User ID tidak valid"})
+	}
+
+	sessionID, err := bson.ObjectIDFromHex(c.Params("sid"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Session ID tidak valid"})
+	}
+
+	if err := revokeSession(c.UserContext(), sessionID, userID); err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return c.Status(404).JSON(fiber.Map{"success": false, "message": "Session tidak ditemukan"})
+		}
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mencabut session", "error": err.Error()})
+	}
+	recordSecurityEvent(c, model.SecurityEventTokensRevoked, &userID, "", bson.M{"scope": "session", "session_id": sessionID.Hex()})
+
+	return c.JSON(fiber.Map{"success": true, "message": "Session user berhasil dicabut"})
+}
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"hello-fiber/app/model"
+	"hello-fiber/app/repository"
+	"hello-fiber/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var tokenRevocationRepo = repository.NewTokenRevocationRepositoryMongo()
+
+// revocationCache menyimpan hasil pengecekan revocation di memori supaya
+// JWTMiddleware tidak perlu query MongoDB di setiap request.
+// Token yang dicabut disimpan sampai kedaluwarsa; hasil negatif hanya disimpan
+// selama TOKEN_REVOCATION_CACHE_TTL agar pencabutan dari replica lain cepat terlihat.
+type revocationCache struct {
+	mu        sync.RWMutex
+	tokens    map[string]revocationEntry
+	users     map[bson.ObjectID]revocationEntry
+	sessions  map[bson.ObjectID]revocationEntry
+	lastPrune time.Time
+}
+
+type revocationEntry struct {
+	revoked       bool
+	revokedBefore time.Time
+	validUntil    time.Time
+}
+
+var revocations = &revocationCache{
+	tokens:   map[string]revocationEntry{},
+	users:    map[bson.ObjectID]revocationEntry{},
+	sessions: map[bson.ObjectID]revocationEntry{},
+}
+
+func revocationCacheTTL() time.Duration {
+	return utils.GetEnvDuration("TOKEN_REVOCATION_CACHE_TTL", 10*time.Second)
+}
+
+func (rc *revocationCache) getToken(jti string) (revocationEntry, bool) {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	e, ok := rc.tokens[jti]
+	if !ok || time.Now().After(e.validUntil) {
+		return revocationEntry{}, false
+	}
+	return e, true
+}
+
+func (rc *revocationCache) setToken(jti string, e revocationEntry) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.tokens[jti] = e
+	rc.pruneLocked()
+}
+
+func (rc *revocationCache) getUser(userID bson.ObjectID) (revocationEntry, bool) {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	e, ok := rc.users[userID]
+	if !ok || time.Now().After(e.validUntil) {
+		return revocationEntry{}, false
+	}
+	return e, true
+}
+
+func (rc *revocationCache) setUser(userID bson.ObjectID, e revocationEntry) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.users[userID] = e
+	rc.pruneLocked()
+}
+
+func (rc *revocationCache) getSession(sessionID bson.ObjectID) (revocationEntry, bool) {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	e, ok := rc.sessions[sessionID]
+	if !ok || time.Now().After(e.validUntil) {
+		return revocationEntry{}, false
+	}
+	return e, true
+}
+
+func (rc *revocationCache) setSession(sessionID bson.ObjectID, e revocationEntry) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.sessions[sessionID] = e
+	rc.pruneLocked()
+}
+
+// pruneLocked membuang entry kedaluwarsa paling sering sekali per menit
+func (rc *revocationCache) pruneLocked() {
+	now := time.Now()
+	if now.Sub(rc.lastPrune) < time.Minute {
+		return
+	}
+	rc.lastPrune = now
+	for k, e := range rc.tokens {
+		if now.After(e.validUntil) {
+			delete(rc.tokens, k)
+		}
+	}
+	for k, e := range rc.users {
+		if now.After(e.validUntil) {
+			delete(rc.users, k)
+		}
+	}
+	for k, e := range rc.sessions {
+		if now.After(e.validUntil) {
+			delete(rc.sessions, k)
+		}
+	}
+}
+
+// IsTokenRevoked mengecek apakah access token sudah dicabut, baik satu per satu (jti),
+// lewat session (sid), maupun lewat pencabutan semua token milik user.
+func IsTokenRevoked(ctx context.Context, claims *utils.Claims) (bool, error) {
+	if claims.ID != "" {
+		e, ok := revocations.getToken(claims.ID)
+		if !ok {
+			revoked, err := tokenRevocationRepo.IsTokenRevoked(ctx, claims.ID)
+			if err != nil {
+				return false, err
+			}
+			validUntil := time.Now().Add(revocationCacheTTL())
+			if revoked && claims.ExpiresAt != nil {
+				validUntil = claims.ExpiresAt.Time
+			}
+			e = revocationEntry{revoked: revoked, validUntil: validUntil}
+			revocations.setToken(claims.ID, e)
+		}
+		if e.revoked {
+			return true, nil
+		}
+	}
+
+	if revoked, err := userTokensRevoked(ctx, claims.UserID, claims); err != nil || revoked {
+		return revoked, err
+	}
+	// Token impersonasi ikut dicabut saat semua token admin-nya dicabut
+	if claims.IsImpersonation() {
+		if revoked, err := userTokensRevoked(ctx, claims.Actor.Subject, claims); err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	// Token lama tanpa sid tidak terikat ke session
+	if claims.SessionID == "" {
+		return false, nil
+	}
+	sessionID, err := bson.ObjectIDFromHex(claims.SessionID)
+	if err != nil {
+		return true, nil
+	}
+
+	e, ok := revocations.getSession(sessionID)
+	if !ok {
+		// Cache miss sekaligus dipakai untuk memperbarui last_seen_at session
+		if err := sessionRepo.TouchSession(ctx, sessionID, utils.GetEnvDuration("SESSION_TOUCH_INTERVAL", time.Minute)); err != nil {
+			return false, err
+		}
+		revoked, err := sessionRepo.IsSessionRevoked(ctx, sessionID)
+		if err != nil {
+			return false, err
+		}
+		validUntil := time.Now().Add(revocationCacheTTL())
+		if revoked && claims.ExpiresAt != nil {
+			validUntil = claims.ExpiresAt.Time
+		}
+		e = revocationEntry{revoked: revoked, validUntil: validUntil}
+		revocations.setSession(sessionID, e)
+	}
+	return e.revoked, nil
+}
+
+// userTokensRevoked mengecek apakah token diterbitkan sebelum semua token milik user dicabut
+func userTokensRevoked(ctx context.Context, userIDHex string, claims *utils.Claims) (bool, error) {
+	userID, err := bson.ObjectIDFromHex(userIDHex)
+	if err != nil {
+		return true, nil
+	}
+
+	e, ok := revocations.getUser(userID)
+	if !ok {
+		before, err := tokenRevocationRepo.GetUserRevokedBefore(ctx, userID)
+		if err != nil {
+			return false, err
+		}
+		e = revocationEntry{validUntil: time.Now().Add(revocationCacheTTL())}
+		if before != nil {
+			e.revokedBefore = *before
+		}
+		revocations.setUser(userID, e)
+	}
+
+	// Token yang iat-nya sama dengan batas (detik yang sama dengan pencabutan) ikut dicabut
+	return !e.revokedBefore.IsZero() && (claims.IssuedAt == nil || !claims.IssuedAt.Time.After(e.revokedBefore)), nil
+}
+
+// revokeAccessToken mencabut satu access token sampai waktu kedaluwarsanya
+func revokeAccessToken(ctx context.Context, jti string, userID bson.ObjectID, expiresAt time.Time) error {
+	if err := tokenRevocationRepo.RevokeToken(ctx, jti, userID, expiresAt); err != nil {
+		return err
+	}
+	revocations.setToken(jti, revocationEntry{revoked: true, validUntil: expiresAt})
+	return nil
+}
+
+// revokeAllUserTokens mencabut semua access token, refresh token, dan session milik user
+func revokeAllUserTokens(ctx context.Context, userID bson.ObjectID) error {
+	// iat JWT memakai presisi detik, jadi batasnya dibulatkan ke bawah dan token dengan iat
+	// sama dengan batas dianggap dicabut (lihat IsTokenRevoked). Token yang terbit di detik
+	// yang sama setelah pencabutan ikut tidak berlaku, user cukup login ulang.
+	// Catatan pencabutan disimpan selama token terpanjang yang mungkin masih berlaku,
+	// termasuk token impersonation (IMPERSONATION_TTL).
+	before := time.Now().Truncate(time.Second)
+	expiresAt := before.Add(utils.GetMaxAccessTokenTTL())
+	if err := tokenRevocationRepo.RevokeAllForUser(ctx, userID, before, expiresAt); err != nil {
+		return err
+	}
+	revocations.setUser(userID, revocationEntry{revokedBefore: before, validUntil: expiresAt})
+	if _, err := sessionRepo.RevokeAllForUser(ctx, userID, bson.NilObjectID); err != nil {
+		return err
+	}
+	// Status dan role user dibaca ulang dari database, supaya api key miliknya ikut
+	// mengikuti perubahan role atau penghapusan akun tanpa menunggu cache kedaluwarsa
+	userStatuses.invalidate(userID)
+	return refreshTokenRepo.RevokeAllForUser(ctx, userID)
+}
+
+// LogoutService mencabut access token yang sedang dipakai beserta session-nya (dan family refresh token-nya)
+func LogoutService(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "User ID tidak valid"})
+	}
+
+	var req model.LogoutRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"success": false, "message": "Request body tidak valid", "error": err.Error()})
+		}
+	}
+
+	jti, _ := c.Locals("jti").(string)
+	expiresAt, _ := c.Locals("token_expires_at").(time.Time)
+	if jti != "" {
+		if err := revokeAccessToken(c.UserContext(), jti, userID, expiresAt); err != nil {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal logout", "error": err.Error()})
+		}
+	}
+
+	if sessionID, err := currentSessionID(c); err == nil {
+		if err := revokeSession(c.UserContext(), sessionID, userID); err != nil && err != repository.ErrSessionNotFound {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mencabut session", "error": err.Error()})
+		}
+	}
+
+	if req.RefreshToken != "" {
+		if err := refreshTokenRepo.RevokeFamilyByTokenHash(c.UserContext(), utils.HashOpaqueToken(req.RefreshToken), userID); err != nil && err != repository.ErrRefreshTokenInvalid {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mencabut refresh token", "error": err.Error()})
+		}
+	}
+
+	return c.JSON(fiber.Map{"success": true, "message": "Logout berhasil"})
+}
+
+// RevokeUserTokensService (admin) mencabut semua token milik user tertentu
+func RevokeUserTokensService(c *fiber.Ctx) error {
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "User ID tidak valid"})
+	}
+
+	if _, err := userRepo.GetUserByID(c.UserContext(), id); err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "User tidak ditemukan", "error": err.Error()})
+	}
+
+	if err := revokeAllUserTokens(c.UserContext(), id); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mencabut token user", "error": err.Error()})
+	}
+	recordSecurityEvent(c, model.SecurityEventTokensRevoked, &id, "", bson.M{"scope": "all"})
+
+	return c.JSON(fiber.Map{"success": true, "message": "Semua token user berhasil dicabut"})
+}
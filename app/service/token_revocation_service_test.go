@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hello-fiber/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestUserTokensRevokedBoundary(t *testing.T) {
+	userID := bson.NewObjectID()
+	before := time.Now().Truncate(time.Second)
+	revocations.setUser(userID, revocationEntry{revokedBefore: before, validUntil: before.Add(time.Hour)})
+
+	tests := []struct {
+		name     string
+		issuedAt *jwt.NumericDate
+		want     bool
+	}{
+		{name: "terbit sebelum pencabutan", issuedAt: jwt.NewNumericDate(before.Add(-time.Second)), want: true},
+		{name: "terbit di detik yang sama", issuedAt: jwt.NewNumericDate(before), want: true},
+		{name: "terbit setelah pencabutan", issuedAt: jwt.NewNumericDate(before.Add(time.Second)), want: false},
+		{name: "tanpa iat", issuedAt: nil, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := &utils.Claims{RegisteredClaims: jwt.RegisteredClaims{IssuedAt: tt.issuedAt}}
+			got, err := userTokensRevoked(context.Background(), userID.Hex(), claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("userTokensRevoked = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
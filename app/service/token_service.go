@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"hello-fiber/app/model"
+	"hello-fiber/app/repository"
+	"hello-fiber/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var refreshTokenRepo = repository.NewRefreshTokenRepositoryMongo()
+
+// issueTokens membuat access token dan refresh token baru.
+// familyID kosong berarti login baru (family dan session baru), selain itu hasil rotasi.
+// amr dicatat di refresh token supaya token hasil rotasi tetap membawa metode login yang sama.
+func issueTokens(c *fiber.Ctx, user *model.User, familyID bson.ObjectID, amr []string) (*model.TokenResponse, error) {
+	refreshToken, refreshHash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(utils.GetRefreshTokenTTL())
+	userAgent := c.Get(fiber.HeaderUserAgent)
+	newLogin := familyID.IsZero()
+	if newLogin {
+		familyID = bson.NewObjectID()
+		if err := sessionRepo.CreateSession(c.UserContext(), &model.Session{
+			ID:        familyID,
+			UserID:    user.ID,
+			UserAgent: userAgent,
+			IP:        c.IP(),
+			AMR:       amr,
+			ExpiresAt: expiresAt,
+		}); err != nil {
+			return nil, err
+		}
+	} else if err := sessionRepo.RefreshSession(c.UserContext(), familyID, userAgent, c.IP(), expiresAt); err != nil {
+		return nil, err
+	}
+
+	accessToken, err := utils.GenerateJWT(*user, familyID.Hex(), amr...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := refreshTokenRepo.CreateRefreshToken(c.UserContext(), &model.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: refreshHash,
+		AMR:       amr,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return nil, err
+	}
+
+	if newLogin {
+		recordSecurityEvent(c, model.SecurityEventLoginSuccess, &user.ID, user.Email, bson.M{"amr": amr, "session_id": familyID.Hex()})
+	}
+
+	return &model.TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(utils.GetAccessTokenTTL().Seconds()),
+	}, nil
+}
+
+// RefreshTokenService menukar refresh token dengan pasangan token baru (rotasi).
+// Refresh token lama yang dipakai ulang akan mencabut seluruh family-nya.
+func RefreshTokenService(c *fiber.Ctx) error {
+	var req model.RefreshTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Request body tidak valid", "error": err.Error()})
+	}
+
+	if req.RefreshToken == "" {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "refresh_token harus diisi"})
+	}
+
+	current, err := refreshTokenRepo.ConsumeRefreshToken(c.UserContext(), utils.HashOpaqueToken(req.RefreshToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrRefreshTokenReused) {
+			_ = revokeSession(c.UserContext(), current.FamilyID, current.UserID)
+			recordSecurityEvent(c, model.SecurityEventTokensRevoked, &current.UserID, "", bson.M{"scope": "session", "session_id": current.FamilyID.Hex(), "reason": "refresh_token_reuse"})
+			return c.Status(401).JSON(fiber.Map{"success": false, "message": "Refresh token sudah pernah digunakan, semua sesi terkait telah dicabut"})
+		}
+		if errors.Is(err, repository.ErrRefreshTokenInvalid) {
+			return c.Status(401).JSON(fiber.Map{"success": false, "message": "Refresh token tidak valid atau kedaluwarsa"})
+		}
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal memproses refresh token", "error": err.Error()})
+	}
+
+	user, err := userRepo.GetUserByID(c.UserContext(), current.UserID)
+	if err != nil {
+		_ = revokeSession(c.UserContext(), current.FamilyID, current.UserID)
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "User tidak ditemukan"})
+	}
+
+	if user.EffectiveStatus(time.Now()) != model.UserStatusActive {
+		_ = revokeSession(c.UserContext(), current.FamilyID, current.UserID)
+		return accountStatusError(c, user)
+	}
+
+	tokens, err := issueTokens(c, user, current.FamilyID, current.AMR)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal membuat token", "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":       true,
+		"message":       "Token berhasil diperbarui",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"token_type":    tokens.TokenType,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
+
+// JWKSService mempublikasikan public key verifikasi JWT agar service lain bisa memverifikasi token
+func JWKSService(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(utils.JWKS())
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"hello-fiber/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func refreshRequest(t *testing.T, app *fiber.App, refreshToken string) (int, string) {
+	t.Helper()
+	body, _ := json.Marshal(fiber.Map{"refresh_token": refreshToken})
+	req := httptest.NewRequest("POST", "/refresh", strings.NewReader(string(body)))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var res struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&res)
+	return resp.StatusCode, res.RefreshToken
+}
+
+func TestRefreshTokenRotationAndReuseDetection(t *testing.T) {
+	requireTestMongo(t)
+	user := createTestUser(t)
+
+	app := fiber.New()
+	app.Post("/login", func(c *fiber.Ctx) error {
+		tokens, err := issueTokens(c, user, bson.NilObjectID, []string{utils.AMRPassword})
+		if err != nil {
+			return err
+		}
+		return c.JSON(fiber.Map{"refresh_token": tokens.RefreshToken})
+	})
+	app.Post("/refresh", RefreshTokenService)
+
+	resp, err := app.Test(httptest.NewRequest("POST", "/login", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var login struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&login); err != nil || login.RefreshToken == "" {
+		t.Fatalf("login gagal: %v", err)
+	}
+	resp.Body.Close()
+
+	// tokens[i] adalah refresh token hasil langkah ke-i (tokens[0] dari login)
+	tokens := []string{login.RefreshToken}
+	steps := []struct {
+		name       string
+		use        func() string
+		wantStatus int
+		rotates    bool
+	}{
+		{name: "rotasi pertama", use: func() string { return tokens[0] }, wantStatus: fiber.StatusOK, rotates: true},
+		{name: "rotasi kedua", use: func() string { return tokens[1] }, wantStatus: fiber.StatusOK, rotates: true},
+		{name: "token lama dipakai ulang", use: func() string { return tokens[0] }, wantStatus: fiber.StatusUnauthorized},
+		{name: "token terbaru ikut dicabut", use: func() string { return tokens[2] }, wantStatus: fiber.StatusUnauthorized},
+		{name: "token tidak dikenal", use: func() string { return "token-palsu" }, wantStatus: fiber.StatusUnauthorized},
+		{name: "token kosong", use: func() string { return "" }, wantStatus: fiber.StatusBadRequest},
+	}
+
+	for _, step := range steps {
+		status, next := refreshRequest(t, app, step.use())
+		if status != step.wantStatus {
+			t.Fatalf("%s: status %d, want %d", step.name, status, step.wantStatus)
+		}
+		if !step.rotates {
+			continue
+		}
+		for _, old := range tokens {
+			if next == "" || next == old {
+				t.Fatalf("%s: refresh token tidak dirotasi", step.name)
+			}
+		}
+		tokens = append(tokens, next)
+	}
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"hello-fiber/app/model"
+	"hello-fiber/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const recoveryCodeCount = 10
+
+// verifySecondFactor memverifikasi kode TOTP atau kode pemulihan (masing-masing hanya bisa dipakai sekali)
+func verifySecondFactor(ctx context.Context, user *model.User, code, recoveryCode string) (bool, error) {
+	if code != "" {
+		step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now())
+		if !ok {
+			return false, nil
+		}
+		return userRepo.ConsumeTOTPStep(ctx, user.ID, step)
+	}
+	if recoveryCode != "" {
+		return userRepo.ConsumeRecoveryCode(ctx, user.ID, utils.HashOpaqueToken(utils.NormalizeRecoveryCode(recoveryCode)))
+	}
+	return false, nil
+}
+
+// newRecoveryCodes membuat kode pemulihan baru, mengembalikan kode asli (untuk user) dan hash-nya (untuk database)
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = utils.HashOpaqueToken(code)
+	}
+	return codes, hashes, nil
+}
+
+// TwoFactorSetupService membuat secret TOTP baru (belum aktif sampai dikonfirmasi lewat enable)
+func TwoFactorSetupService(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "User tidak ditemukan"})
+	}
+
+	if user.TOTPEnabled {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "2FA sudah aktif"})
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal membuat secret 2FA", "error": err.Error()})
+	}
+
+	if err := userRepo.SetPendingTOTPSecret(c.UserContext(), user.ID, secret); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal menyimpan secret 2FA", "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":     true,
+		"message":     "Scan QR code lalu konfirmasi dengan kode dari aplikasi authenticator",
+		"secret":      secret,
+		"otpauth_uri": utils.TOTPProvisioningURI(secret, user.Email, utils.GetEnv("TOTP_ISSUER", "Alumni App")),
+	})
+}
+
+// TwoFactorEnableService mengaktifkan 2FA setelah user mengirim kode TOTP yang valid
+func TwoFactorEnableService(c *fiber.Ctx) error {
+	var req model.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Request body tidak valid", "error": err.Error()})
+	}
+
+	user, err := currentUser(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "User tidak ditemukan"})
+	}
+
+	if user.TOTPEnabled {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "2FA sudah aktif"})
+	}
+	if user.TOTPPendingSecret == "" {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Jalankan setup 2FA terlebih dahulu"})
+	}
+
+	step, ok := utils.ValidateTOTP(user.TOTPPendingSecret, req.Code, time.Now())
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Kode 2FA tidak valid"})
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal membuat kode pemulihan", "error": err.Error()})
+	}
+
+	if err := userRepo.EnableTOTP(c.UserContext(), user.ID, user.TOTPPendingSecret, step, hashes); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mengaktifkan 2FA", "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":        true,
+		"message":        "2FA berhasil diaktifkan. Simpan kode pemulihan ini, kode hanya ditampilkan sekali",
+		"recovery_codes": codes,
+	})
+}
+
+// TwoFactorDisableService menonaktifkan 2FA, membutuhkan password dan kode TOTP/kode pemulihan
+func TwoFactorDisableService(c *fiber.Ctx) error {
+	var req model.DisableTwoFactorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Request body tidak valid", "error": err.Error()})
+	}
+
+	user, err := currentUser(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "User tidak ditemukan"})
+	}
+
+	if !user.TOTPEnabled {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "2FA belum aktif"})
+	}
+
+	if !utils.CheckPassword(req.Password, user.Password) {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Password salah"})
+	}
+
+	// Akun yang disuspend setelah challenge diterbitkan ditolak sebelum kode diverifikasi,
+	// supaya kode yang benar tidak terpakai dan tidak mereset penghitung lockout
+	if err := accountStatusError(c, user); err != nil {
+		loginFailed(c, &user.ID, user.Email, "account_"+user.EffectiveStatus(time.Now()))
+		return err
+	}
+
+	ok, err := verifySecondFactor(c.UserContext(), user, req.Code, req.RecoveryCode)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal memverifikasi kode 2FA", "error": err.Error()})
+	}
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Kode 2FA tidak valid"})
+	}
+
+	if err := userRepo.DisableTOTP(c.UserContext(), user.ID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal menonaktifkan 2FA", "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "message": "2FA berhasil dinonaktifkan"})
+}
+
+// RegenerateRecoveryCodesService mengganti semua kode pemulihan (kode lama tidak berlaku lagi)
+func RegenerateRecoveryCodesService(c *fiber.Ctx) error {
+	var req model.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Request body tidak valid", "error": err.Error()})
+	}
+
+	user, err := currentUser(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "User tidak ditemukan"})
+	}
+
+	if !user.TOTPEnabled {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "2FA belum aktif"})
+	}
+
+	ok, err := verifySecondFactor(c.UserContext(), user, req.Code, "")
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal memverifikasi kode 2FA", "error": err.Error()})
+	}
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Kode 2FA tidak valid"})
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal membuat kode pemulihan", "error": err.Error()})
+	}
+
+	if err := userRepo.SetRecoveryCodes(c.UserContext(), user.ID, hashes); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal menyimpan kode pemulihan", "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "message": "Kode pemulihan berhasil dibuat ulang", "recovery_codes": codes})
+}
+
+// LoginTwoFactorService adalah langkah kedua login: menukar challenge token + kode 2FA dengan token akses
+func LoginTwoFactorService(c *fiber.Ctx) error {
+	var req model.TwoFactorLoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Request body tidak valid", "error": err.Error()})
+	}
+
+	if req.ChallengeToken == "" || (req.Code == "" && req.RecoveryCode == "") {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "challenge_token dan code (atau recovery_code) harus diisi"})
+	}
+
+	claims, err := utils.ParseTwoFactorChallengeToken(req.ChallengeToken)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Challenge token tidak valid atau kedaluwarsa"})
+	}
+
+	id, err := bson.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Challenge token tidak valid"})
+	}
+
+	user, err := userRepo.GetUserByID(c.UserContext(), id)
+	if err != nil || !user.TOTPEnabled {
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Challenge token tidak valid"})
+	}
+
+	// Kode 2FA yang salah dihitung sebagai kegagalan login agar tidak bisa di-brute-force
+	retryAfter, err := loginLockRemaining(c.UserContext(), user.Email, c.IP())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal memeriksa status login", "error": err.Error()})
+	}
+	if retryAfter > 0 {
+		return tooManyLoginAttempts(c, retryAfter)
+	}
+
+	// Akun yang disuspend setelah challenge diterbitkan ditolak sebelum kode diverifikasi,
+	// supaya kode yang benar tidak terpakai dan tidak mereset penghitung lockout
+	if err := accountStatusError(c, user); err != nil {
+		loginFailed(c, &user.ID, user.Email, "account_"+user.EffectiveStatus(time.Now()))
+		return err
+	}
+
+	ok, err := verifySecondFactor(c.UserContext(), user, req.Code, req.RecoveryCode)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal memverifikasi kode 2FA", "error": err.Error()})
+	}
+	if !ok {
+		loginFailed(c, &user.ID, user.Email, "invalid_2fa_code")
+		if err := recordLoginFailure(c, user.Email); err != nil {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mencatat percobaan login", "error": err.Error()})
+		}
+		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Kode 2FA tidak valid"})
+	}
+
+	if err := resetLoginFailures(c.UserContext(), user.Email); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal memperbarui status login", "error": err.Error()})
+	}
+
+	tokens, err := issueTokens(c, user, bson.NilObjectID, []string{utils.AMRPassword, utils.AMROTP})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal membuat token", "error": err.Error()})
+	}
+	utils.ObserveLogin(true)
+
+	return c.JSON(fiber.Map{
+		"success":       true,
+		"message":       "Login berhasil",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"token_type":    tokens.TokenType,
+		"expires_in":    tokens.ExpiresIn,
+		"user":          toUserResponse(user),
+	})
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+
+	"hello-fiber/utils"
+)
+
+// testTOTPCode menghitung kode TOTP (RFC 6238, SHA1, 6 digit, 30 detik) untuk waktu t
+func testTOTPCode(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(at.Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:offset+4])&0x7fffffff)%1000000)
+}
+
+func TestVerifySecondFactorRejectsReplay(t *testing.T) {
+	requireTestMongo(t)
+	ctx := context.Background()
+	user := createTestUser(t)
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Step saat enable dibuat lebih lama supaya kode saat ini belum terpakai
+	if err := userRepo.EnableTOTP(ctx, user.ID, secret, time.Now().Unix()/30-2, nil); err != nil {
+		t.Fatal(err)
+	}
+	user, err = userRepo.GetUserByID(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	tests := []struct {
+		name string
+		code string
+		want bool
+	}{
+		{name: "kode saat ini", code: testTOTPCode(t, secret, now), want: true},
+		{name: "kode yang sama dipakai ulang", code: testTOTPCode(t, secret, now)},
+		{name: "kode step sebelumnya setelah step lebih baru dipakai", code: testTOTPCode(t, secret, now.Add(-30*time.Second))},
+		{name: "kode salah", code: "000000"},
+	}
+
+	for _, tt := range tests {
+		ok, err := verifySecondFactor(ctx, user, tt.code, "")
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if ok != tt.want {
+			t.Errorf("%s: verifySecondFactor = %v, want %v", tt.name, ok, tt.want)
+		}
+	}
+}
+
+func TestVerifySecondFactorRecoveryCodeIsSingleUse(t *testing.T) {
+	requireTestMongo(t)
+	ctx := context.Background()
+	user := createTestUser(t)
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := userRepo.EnableTOTP(ctx, user.ID, "JBSWY3DPEHPK3PXP", 0, hashes); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, want := range []bool{true, false} {
+		ok, err := verifySecondFactor(ctx, user, "", codes[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != want {
+			t.Errorf("pemakaian ke-%d: verifySecondFactor = %v, want %v", i+1, ok, want)
+		}
+	}
+}
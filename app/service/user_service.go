@@ -1,15 +1,16 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"hello-fiber/app/model"
 	"hello-fiber/app/repository"
 	"hello-fiber/utils"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/v2/bson"
-	"golang.org/x/crypto/bcrypt"
 	"regexp"
 	"strings"
-	"unicode"
+	"time"
 )
 
 var userRepo = repository.NewUserRepositoryMongo()
@@ -32,28 +33,24 @@ func isValidUsername(username string) bool {
 	return true
 }
 
-func isValidPassword(password string) bool {
-	if len(password) < 5 {
-		return false
+// validatePassword memeriksa password terhadap kebijakan password. Untuk user yang sudah ada,
+// hash password saat ini dan riwayatnya ikut diperiksa agar password lama tidak dipakai ulang.
+func validatePassword(password string, user *model.User) []utils.PasswordViolation {
+	policy := utils.GetPasswordPolicy()
+	if user == nil {
+		return policy.Validate(password)
 	}
+	return policy.Validate(password, policy.RecentPasswordHashes(user.Password, user.PasswordHistory)...)
+}
 
-	hasUpper := true
-	hasLower := true
-	hasNumber := true
-
-	for _, char := range password {
-		if unicode.IsUpper(char) {
-			hasUpper = true
-		}
-		if unicode.IsLower(char) {
-			hasLower = true
-		}
-		if unicode.IsDigit(char) {
-			hasNumber = true
-		}
-	}
+// passwordPolicyError mengirim semua aturan kebijakan password yang dilanggar
+func passwordPolicyError(c *fiber.Ctx, violations []utils.PasswordViolation) error {
+	return c.Status(400).JSON(fiber.Map{"success": false, "message": "Password tidak memenuhi kebijakan password", "errors": violations})
+}
 
-	return hasUpper && hasLower && hasNumber
+// changePassword mengganti password user sambil menyimpan riwayat hash sesuai PASSWORD_HISTORY
+func changePassword(ctx context.Context, userID bson.ObjectID, password string) error {
+	return userRepo.ChangePassword(ctx, userID, password, utils.GetPasswordPolicy().HistorySize-1)
 }
 
 func toUserResponse(user *model.User) *model.UserResponse {
@@ -64,6 +61,13 @@ func toUserResponse(user *model.User) *model.UserResponse {
 		AlumniID:  user.AlumniID,
 		RoleID:    user.RoleID,
 		CreatedAt: user.CreatedAt,
+
+		EmailVerified: user.EmailVerified,
+		TOTPEnabled:   user.TOTPEnabled,
+
+		Status:          user.EffectiveStatus(time.Now()),
+		SuspendedReason: user.SuspendedReason,
+		SuspendedUntil:  user.SuspendedUntil,
 	}
 }
 
@@ -71,6 +75,34 @@ func isObjectIDEmpty(id *bson.ObjectID) bool {
 	return id == nil || id.IsZero()
 }
 
+// currentUserID mengambil ID user yang sedang login dari locals JWTMiddleware
+func currentUserID(c *fiber.Ctx) (bson.ObjectID, error) {
+	userIDStr, _ := c.Locals("user_id").(string)
+	return bson.ObjectIDFromHex(userIDStr)
+}
+
+// currentUser mengambil dokumen user yang sedang login
+func currentUser(c *fiber.Ctx) (*model.User, error) {
+	id, err := currentUserID(c)
+	if err != nil {
+		return nil, err
+	}
+	return userRepo.GetUserByID(c.UserContext(), id)
+}
+
+// emailRegistered mengecek apakah email sudah dipakai user lain. Error database dikembalikan
+// apa adanya supaya tidak dianggap sebagai email yang masih bebas.
+func emailRegistered(ctx context.Context, email string) (bool, error) {
+	_, err := userRepo.GetUserByEmail(ctx, email)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func Register(c *fiber.Ctx) error {
 	var req model.RegisterRequest
 	if err := c.BodyParser(&req); err != nil {
@@ -89,11 +121,11 @@ func Register(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Format email tidak valid"})
 	}
 
-	if !isValidPassword(req.Password) {
-		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Password minimal 5 karakter"})
+	if violations := validatePassword(req.Password, nil); len(violations) > 0 {
+		return passwordPolicyError(c, violations)
 	}
 
-	existingUser, err := userRepo.GetUserByUsername(req.Username)
+	existingUser, err := userRepo.GetUserByUsername(c.UserContext(), req.Username)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal validasi username", "error": err.Error()})
 	}
@@ -101,16 +133,74 @@ func Register(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Username sudah terdaftar"})
 	}
 
-	if req.RoleID == bson.NilObjectID {
-		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Role ID harus diisi"})
+	registered, err := emailRegistered(c.UserContext(), strings.ToLower(strings.TrimSpace(req.Email)))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal validasi email", "error": err.Error()})
+	}
+	if registered {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Email sudah terdaftar"})
+	}
+
+	// Role tidak boleh dipilih sendiri oleh client (mencegah daftar langsung sebagai admin)
+	if req.RoleID != bson.NilObjectID {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "role_id tidak boleh diisi saat registrasi, gunakan undangan untuk role khusus"})
 	}
 
-	id, err := userRepo.Register(req)
+	var invitation *model.Invitation
+	if req.InviteToken != "" {
+		invitation, err = invitationRepo.AcceptInvitation(c.UserContext(), utils.HashOpaqueToken(req.InviteToken), req.Email)
+		if err != nil {
+			if errors.Is(err, repository.ErrInvitationInvalid) {
+				return c.Status(400).JSON(fiber.Map{"success": false, "message": "Undangan tidak valid, sudah dipakai, atau kedaluwarsa untuk email ini"})
+			}
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal memproses undangan", "error": err.Error()})
+		}
+		// Satu alumni hanya boleh tertaut ke satu akun
+		if invitation.AlumniID != nil {
+			if linked, err := userRepo.GetUserByAlumniID(c.UserContext(), *invitation.AlumniID); err != nil || linked != nil {
+				_ = invitationRepo.ReleaseInvitation(c.UserContext(), invitation.ID)
+				return c.Status(400).JSON(fiber.Map{"success": false, "message": "Data alumni pada undangan ini sudah tertaut ke akun lain"})
+			}
+		}
+		req.RoleID = invitation.RoleID
+		req.AlumniID = invitation.AlumniID
+	} else {
+		role, err := defaultRegistrationRole(c.UserContext())
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mengambil role default", "error": err.Error()})
+		}
+		req.RoleID = role.ID
+	}
+
+	id, err := userRepo.Register(c.UserContext(), req)
 	if err != nil {
+		if invitation != nil {
+			_ = invitationRepo.ReleaseInvitation(c.UserContext(), invitation.ID)
+		}
+		if errors.Is(err, repository.ErrUserExists) {
+			return c.Status(400).JSON(fiber.Map{"success": false, "message": "Email atau username sudah terdaftar"})
+		}
 		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mendaftarkan user", "error": err.Error()})
 	}
 
-	return c.Status(201).JSON(fiber.Map{"success": true, "message": "User berhasil didaftarkan", "id": id.Hex()})
+	// Link undangan dikirim ke email tersebut, jadi email dianggap sudah terverifikasi
+	if invitation != nil {
+		if err := invitationRepo.SetAcceptedUser(c.UserContext(), invitation.ID, id); err != nil {
+			requestLogger(c).Error("Error updating invitation", "error", err)
+		}
+		if err := userRepo.MarkEmailVerified(c.UserContext(), id, invitation.Email); err != nil {
+			requestLogger(c).Error("Error marking invited user as verified", "error", err)
+		}
+		return c.Status(201).JSON(fiber.Map{"success": true, "message": "User berhasil didaftarkan", "id": id.Hex()})
+	}
+
+	if user, err := userRepo.GetUserByID(c.UserContext(), id); err == nil {
+		if err := sendVerificationEmail(c.UserContext(), user); err != nil {
+			requestLogger(c).Error("Error sending verification email", "error", err)
+		}
+	}
+
+	return c.Status(201).JSON(fiber.Map{"success": true, "message": "User berhasil didaftarkan, silakan cek email untuk verifikasi", "id": id.Hex()})
 }
 
 func CreateUserAdmin(c *fiber.Ctx) error {
@@ -131,11 +221,11 @@ func CreateUserAdmin(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Format email tidak valid"})
 	}
 
-	if !isValidPassword(req.Password) {
-		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Password minimal 8 karakter, harus ada uppercase, lowercase, dan number"})
+	if violations := validatePassword(req.Password, nil); len(violations) > 0 {
+		return passwordPolicyError(c, violations)
 	}
 
-	existingUser, err := userRepo.GetUserByUsername(req.Username)
+	existingUser, err := userRepo.GetUserByUsername(c.UserContext(), req.Username)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal validasi username", "error": err.Error()})
 	}
@@ -143,23 +233,51 @@ func CreateUserAdmin(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Username sudah terdaftar"})
 	}
 
+	registered, err := emailRegistered(c.UserContext(), strings.ToLower(strings.TrimSpace(req.Email)))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal validasi email", "error": err.Error()})
+	}
+	if registered {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Email sudah terdaftar"})
+	}
+
 	if req.RoleID == bson.NilObjectID {
 		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Role ID harus diisi"})
 	}
 
-	_, err = userRepo.GetRoleByID(req.RoleID)
+	role, err := userRepo.GetRoleByID(c.UserContext(), req.RoleID)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Role ID tidak valid", "error": err.Error()})
 	}
+	if !canGrantRole(c, role) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Tidak boleh memberikan role dengan permission lebih tinggi dari role Anda"})
+	}
 
-	id, err := userRepo.CreateUser(req)
+	id, err := userRepo.CreateUser(c.UserContext(), req)
 	if err != nil {
+		if errors.Is(err, repository.ErrUserExists) {
+			return c.Status(400).JSON(fiber.Map{"success": false, "message": "Email atau username sudah terdaftar"})
+		}
 		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal membuat user", "error": err.Error()})
 	}
 
+	if !req.SkipVerification {
+		if user, err := userRepo.GetUserByID(c.UserContext(), id); err == nil {
+			if err := sendVerificationEmail(c.UserContext(), user); err != nil {
+				requestLogger(c).Error("Error sending verification email", "error", err)
+			}
+		}
+	}
+
 	return c.Status(201).JSON(fiber.Map{"success": true, "message": "User berhasil dibuat", "id": id.Hex()})
 }
 
+// loginFailed mencatat percobaan login yang gagal ke security event dan metrik login
+func loginFailed(c *fiber.Ctx, userID *bson.ObjectID, email, reason string) {
+	utils.ObserveLogin(false)
+	recordSecurityEvent(c, model.SecurityEventLoginFailure, userID, email, bson.M{"reason": reason})
+}
+
 func LoginService(c *fiber.Ctx) error {
 	var req model.LoginRequest
 	if err := c.BodyParser(&req); err != nil {
@@ -170,21 +288,85 @@ func LoginService(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Email dan password harus diisi"})
 	}
 
-	user, err := userRepo.GetUserByEmail(strings.ToLower(strings.TrimSpace(req.Email)))
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	retryAfter, err := loginLockRemaining(c.UserContext(), email, c.IP())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal memeriksa status login", "error": err.Error()})
+	}
+	if retryAfter > 0 {
+		loginFailed(c, nil, email, "locked_out")
+		return tooManyLoginAttempts(c, retryAfter)
+	}
+
+	user, err := userRepo.GetUserByEmail(c.UserContext(), email)
 	if err != nil {
+		loginFailed(c, nil, email, "unknown_email")
+		if err := recordLoginFailure(c, email); err != nil {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mencatat percobaan login", "error": err.Error()})
+		}
 		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Email atau password salah"})
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+	if !utils.CheckPassword(req.Password, user.Password) {
+		loginFailed(c, &user.ID, email, "invalid_password")
+		if err := recordLoginFailure(c, email); err != nil {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mencatat percobaan login", "error": err.Error()})
+		}
 		return c.Status(401).JSON(fiber.Map{"success": false, "message": "Email atau password salah"})
 	}
 
-	token, err := utils.GenerateJWT(*user)
+	// Hash lama (bcrypt atau parameter Argon2id usang) di-upgrade selagi password asli tersedia
+	if utils.PasswordNeedsRehash(user.Password) {
+		if newHash, err := utils.HashPassword(req.Password); err == nil {
+			if err := userRepo.UpgradePasswordHash(c.UserContext(), user.ID, user.Password, newHash); err != nil {
+				requestLogger(c).Error("Error upgrading password hash", "error", err)
+			}
+		}
+	}
+
+	if err := accountStatusError(c, user); err != nil {
+		loginFailed(c, &user.ID, email, "account_"+user.EffectiveStatus(time.Now()))
+		return err
+	}
+
+	if !user.EmailVerified && EmailVerificationPolicy() == EmailVerificationReject {
+		loginFailed(c, &user.ID, email, "email_unverified")
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Email belum diverifikasi, silakan cek email Anda"})
+	}
+
+	if user.TOTPEnabled {
+		challenge, err := utils.GenerateTwoFactorChallengeToken(*user)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal membuat challenge 2FA", "error": err.Error()})
+		}
+		return c.JSON(fiber.Map{
+			"success":             true,
+			"message":             "Masukkan kode 2FA untuk melanjutkan login",
+			"two_factor_required": true,
+			"challenge_token":     challenge,
+		})
+	}
+
+	if err := resetLoginFailures(c.UserContext(), email); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal memperbarui status login", "error": err.Error()})
+	}
+
+	tokens, err := issueTokens(c, user, bson.NilObjectID, []string{utils.AMRPassword})
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal membuat token", "error": err.Error()})
 	}
+	utils.ObserveLogin(true)
 
-	return c.JSON(fiber.Map{"success": true, "message": "Login berhasil", "token": token, "user": toUserResponse(user)})
+	return c.JSON(fiber.Map{
+		"success":       true,
+		"message":       "Login berhasil",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"token_type":    tokens.TokenType,
+		"expires_in":    tokens.ExpiresIn,
+		"user":          toUserResponse(user),
+	})
 }
 
 func GetAllUsersService(c *fiber.Ctx) error {
@@ -198,7 +380,7 @@ func GetAllUsersService(c *fiber.Ctx) error {
 		limit = int64(c.QueryInt("limit", 10))
 	}
 
-	users, total, err := userRepo.GetAllUsers(page, limit)
+	users, total, err := userRepo.GetAllUsers(c.UserContext(), page, limit)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mengambil data user", "error": err.Error()})
 	}
@@ -241,18 +423,45 @@ func UpdateUserService(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Format email tidak valid"})
 	}
 
-	if req.Password != "" && !isValidPassword(req.Password) {
-		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Password minimal 5 karakter"})
-	}
-
 	id, err := bson.ObjectIDFromHex(userID)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"success": false, "message": "User ID tidak valid"})
 	}
 
+	target, err := userRepo.GetUserByID(c.UserContext(), id)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "User tidak ditemukan"})
+	}
+
+	// Admin tidak boleh mengubah (termasuk reset password/email) user dengan role lebih tinggi
+	targetRole, err := getRole(c.UserContext(), target.RoleID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mengambil role user", "error": err.Error()})
+	}
+	if !canGrantRole(c, targetRole) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Tidak dapat mengubah user dengan role lebih tinggi"})
+	}
+
+	roleChanged := req.RoleID != bson.NilObjectID && req.RoleID != target.RoleID
+	if roleChanged {
+		newRole, err := userRepo.GetRoleByID(c.UserContext(), req.RoleID)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"success": false, "message": "Role ID tidak valid", "error": err.Error()})
+		}
+		if !canGrantRole(c, newRole) {
+			return c.Status(403).JSON(fiber.Map{"success": false, "message": "Tidak boleh memberikan role dengan permission lebih tinggi dari role Anda"})
+		}
+	}
+
+	if req.Password != "" {
+		if violations := validatePassword(req.Password, target); len(violations) > 0 {
+			return passwordPolicyError(c, violations)
+		}
+	}
+
 	// Cek apakah username sudah ada (jika diupdate)
 	if req.Username != "" {
-		existingUser, err := userRepo.GetUserByUsername(req.Username)
+		existingUser, err := userRepo.GetUserByUsername(c.UserContext(), req.Username)
 		if err != nil {
 			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal validasi username", "error": err.Error()})
 		}
@@ -261,8 +470,29 @@ func UpdateUserService(c *fiber.Ctx) error {
 		}
 	}
 
-	if err := userRepo.UpdateUser(id, req); err != nil {
-		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal update user", "error": err.Error()})
+	// Password diganti lewat changePassword supaya riwayatnya tercatat
+	password := req.Password
+	req.Password = ""
+	if req.Username != "" || req.Email != "" || req.RoleID != bson.NilObjectID || req.AlumniID != nil {
+		if err := userRepo.UpdateUser(c.UserContext(), id, req); err != nil {
+			if errors.Is(err, repository.ErrUserExists) {
+				return c.Status(400).JSON(fiber.Map{"success": false, "message": "Email atau username sudah terdaftar"})
+			}
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal update user", "error": err.Error()})
+		}
+		if roleChanged {
+			recordSecurityEvent(c, model.SecurityEventRoleChanged, &id, target.Email, bson.M{"from_role_id": target.RoleID.Hex(), "to_role_id": req.RoleID.Hex()})
+			// Token lama masih membawa role_id lama, cabut supaya role baru langsung berlaku
+			if err := revokeAllUserTokens(c.UserContext(), id); err != nil {
+				return c.Status(500).JSON(fiber.Map{"success": false, "message": "Role diubah tetapi gagal mencabut token", "error": err.Error()})
+			}
+		}
+	}
+	if password != "" {
+		if err := changePassword(c.UserContext(), id, password); err != nil {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mengganti password", "error": err.Error()})
+		}
+		recordSecurityEvent(c, model.SecurityEventPasswordChanged, &id, target.Email, bson.M{"method": "admin"})
 	}
 
 	return c.JSON(fiber.Map{"success": true, "message": "User berhasil diupdate"})
@@ -279,9 +509,50 @@ func DeleteUserService(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"success": false, "message": "User ID tidak valid"})
 	}
 
-	if err := userRepo.DeleteUser(id); err != nil {
+	if currentID, err := currentUserID(c); err == nil && currentID == id {
+		return c.Status(409).JSON(fiber.Map{"success": false, "message": "Tidak dapat menghapus akun sendiri"})
+	}
+
+	target, err := userRepo.GetUserByID(c.UserContext(), id)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return c.Status(404).JSON(fiber.Map{"success": false, "message": "User tidak ditemukan"})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mengambil data user", "error": err.Error()})
+	}
+
+	// Admin tidak boleh menghapus user dengan role lebih tinggi
+	targetRole, err := getRole(c.UserContext(), target.RoleID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mengambil role user", "error": err.Error()})
+	}
+	if !canGrantRole(c, targetRole) {
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Tidak dapat menghapus user dengan role lebih tinggi"})
+	}
+
+	// Pastikan masih ada user dengan permission "*" setelah penghapusan
+	if targetRole.HasAllPermissions() {
+		roles, err := otherSuperAdminRoles(c.UserContext(), bson.NilObjectID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal validasi role", "error": err.Error()})
+		}
+		admins, err := countUsersInRoles(c.UserContext(), roles)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal menghitung user", "error": err.Error()})
+		}
+		if admins <= 1 {
+			return c.Status(409).JSON(fiber.Map{"success": false, "message": "Tidak dapat menghapus satu-satunya user dengan permission \"*\""})
+		}
+	}
+
+	if err := userRepo.DeleteUser(c.UserContext(), id); err != nil {
 		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal delete user", "error": err.Error()})
 	}
 
+	// Token milik user yang dihapus tidak boleh tetap berlaku sampai kedaluwarsa
+	if err := revokeAllUserTokens(c.UserContext(), id); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "User dihapus tetapi gagal mencabut token", "error": err.Error()})
+	}
+
 	return c.JSON(fiber.Map{"success": true, "message": "User berhasil dihapus"})
 }
\ No newline at end of file
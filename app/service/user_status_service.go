@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"hello-fiber/app/model"
+	"hello-fiber/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var userStatusRepo = repository.NewUserStatusRepositoryMongo()
+
+// userStatusCache menyimpan status akun dan role_id user untuk JWTMiddleware dan autentikasi
+// api key, dengan masa simpan yang sama seperti cache revocation (TOKEN_REVOCATION_CACHE_TTL)
+type userStatusCache struct {
+	mu        sync.RWMutex
+	entries   map[bson.ObjectID]userStatusEntry
+	lastPrune time.Time
+}
+
+type userStatusEntry struct {
+	blocked    bool
+	roleID     bson.ObjectID
+	validUntil time.Time
+}
+
+var userStatuses = &userStatusCache{entries: map[bson.ObjectID]userStatusEntry{}}
+
+func (sc *userStatusCache) get(userID bson.ObjectID) (userStatusEntry, bool) {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	e, ok := sc.entries[userID]
+	if !ok || time.Now().After(e.validUntil) {
+		return userStatusEntry{}, false
+	}
+	return e, true
+}
+
+func (sc *userStatusCache) set(userID bson.ObjectID, e userStatusEntry) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	e.validUntil = time.Now().Add(revocationCacheTTL())
+	sc.entries[userID] = e
+	sc.pruneLocked()
+}
+
+// pruneLocked membuang entry kedaluwarsa paling sering sekali per menit
+func (sc *userStatusCache) pruneLocked() {
+	now := time.Now()
+	if now.Sub(sc.lastPrune) < time.Minute {
+		return
+	}
+	sc.lastPrune = now
+	for k, e := range sc.entries {
+		if now.After(e.validUntil) {
+			delete(sc.entries, k)
+		}
+	}
+}
+
+func (sc *userStatusCache) invalidate(userID bson.ObjectID) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	delete(sc.entries, userID)
+}
+
+// userAccess mengambil status akun dan role_id user (dari cache jika ada)
+func userAccess(ctx context.Context, userID bson.ObjectID) (userStatusEntry, error) {
+	if e, ok := userStatuses.get(userID); ok {
+		return e, nil
+	}
+
+	user, err := userRepo.GetUserByID(ctx, userID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		// User yang sudah dihapus tidak boleh memakai token atau api key lamanya
+		e := userStatusEntry{blocked: true}
+		userStatuses.set(userID, e)
+		return e, nil
+	}
+	if err != nil {
+		// Error database tidak di-cache supaya gangguan sesaat tidak mengunci semua user
+		return userStatusEntry{}, err
+	}
+	e := userStatusEntry{blocked: user.EffectiveStatus(time.Now()) != model.UserStatusActive, roleID: user.RoleID}
+	userStatuses.set(userID, e)
+	return e, nil
+}
+
+// IsUserBlocked mengecek apakah akun user sedang disuspend atau dinonaktifkan
+func IsUserBlocked(ctx context.Context, userIDHex string) (bool, error) {
+	userID, err := bson.ObjectIDFromHex(userIDHex)
+	if err != nil {
+		return true, nil
+	}
+
+	e, err := userAccess(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return e.blocked, nil
+}
+
+// accountStatusError mengembalikan response 403 jika akun tidak aktif, nil jika boleh login
+func accountStatusError(c *fiber.Ctx, user *model.User) error {
+	switch user.EffectiveStatus(time.Now()) {
+	case model.UserStatusSuspended:
+		resp := fiber.Map{"success": false, "message": "Akun Anda sedang disuspend", "status": model.UserStatusSuspended}
+		if user.SuspendedReason != "" {
+			resp["reason"] = user.SuspendedReason
+		}
+		if user.SuspendedUntil != nil {
+			resp["until"] = user.SuspendedUntil
+		}
+		return c.Status(403).JSON(resp)
+	case model.UserStatusDeactivated:
+		return c.Status(403).JSON(fiber.Map{"success": false, "message": "Akun Anda sudah dinonaktifkan", "status": model.UserStatusDeactivated})
+	}
+	return nil
+}
+
+// statusTarget mengambil user yang akan diubah statusnya dan memastikan admin berhak mengubahnya
+func statusTarget(c *fiber.Ctx) (*model.User, bson.ObjectID, error) {
+	actorID, err := currentUserID(c)
+	if err != nil {
+		return nil, actorID, c.Status(401).JSON(fiber.Map{"success": false, "message": "User ID tidak valid"})
+	}
+
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return nil, actorID, c.Status(400).JSON(fiber.Map{"success": false, "message": "User ID tidak valid"})
+	}
+	if id == actorID {
+		return nil, actorID, c.Status(400).JSON(fiber.Map{"success": false, "message": "Tidak dapat mengubah status akun sendiri"})
+	}
+
+	user, err := userRepo.GetUserByID(c.UserContext(), id)
+	if err != nil {
+		return nil, actorID, c.Status(404).JSON(fiber.Map{"success": false, "message": "User tidak ditemukan", "error": err.Error()})
+	}
+
+	// Admin tidak boleh menyuspend user dengan role yang lebih tinggi dari role-nya sendiri
+	role, err := getRole(c.UserContext(), user.RoleID)
+	if err != nil {
+		return nil, actorID, c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mengambil role user", "error": err.Error()})
+	}
+	if !canGrantRole(c, role) {
+		return nil, actorID, c.Status(403).JSON(fiber.Map{"success": false, "message": "Tidak dapat mengubah status user dengan role lebih tinggi"})
+	}
+	return user, actorID, nil
+}
+
+// setUserStatus menyimpan status baru, mencatat event-nya, dan memperbarui cache status
+func setUserStatus(ctx context.Context, user *model.User, actorID bson.ObjectID, action, status, reason string, until *time.Time) error {
+	if err := userRepo.SetUserStatus(ctx, user.ID, status, reason, until); err != nil {
+		return err
+	}
+	userStatuses.set(user.ID, userStatusEntry{blocked: status != model.UserStatusActive, roleID: user.RoleID})
+
+	return userStatusRepo.CreateStatusEvent(ctx, &model.UserStatusEvent{
+		UserID:  user.ID,
+		Action:  action,
+		Reason:  reason,
+		Until:   until,
+		ActorID: actorID,
+	})
+}
+
+// SuspendUserService (admin) menyuspend akun, opsional sampai waktu tertentu.
+// Semua token dan session user langsung dicabut.
+func SuspendUserService(c *fiber.Ctx) error {
+	var req model.SuspendUserRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Request body tidak valid", "error": err.Error()})
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Alasan suspend harus diisi"})
+	}
+	if req.Until != nil && !req.Until.After(time.Now()) {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "Batas waktu suspend harus di masa depan"})
+	}
+
+	user, actorID, err := statusTarget(c)
+	if user == nil {
+		return err
+	}
+
+	if err := setUserStatus(c.UserContext(), user, actorID, "suspend", model.UserStatusSuspended, req.Reason, req.Until); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal menyuspend user", "error": err.Error()})
+	}
+	if err := revokeAllUserTokens(c.UserContext(), user.ID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mencabut token user", "error": err.Error()})
+	}
+	recordSecurityEvent(c, model.SecurityEventTokensRevoked, &user.ID, user.Email, bson.M{"scope": "all", "reason": "account_suspended"})
+
+	return c.JSON(fiber.Map{"success": true, "message": "User berhasil disuspend"})
+}
+
+// DeactivateUserService (admin) menonaktifkan akun secara permanen sampai diaktifkan kembali
+func DeactivateUserService(c *fiber.Ctx) error {
+	var req model.UserStatusRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"success": false, "message": "Request body tidak valid", "error": err.Error()})
+		}
+	}
+
+	user, actorID, err := statusTarget(c)
+	if user == nil {
+		return err
+	}
+
+	if err := setUserStatus(c.UserContext(), user, actorID, "deactivate", model.UserStatusDeactivated, strings.TrimSpace(req.Reason), nil); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal menonaktifkan user", "error": err.Error()})
+	}
+	if err := revokeAllUserTokens(c.UserContext(), user.ID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mencabut token user", "error": err.Error()})
+	}
+	recordSecurityEvent(c, model.SecurityEventTokensRevoked, &user.ID, user.Email, bson.M{"scope": "all", "reason": "account_deactivated"})
+
+	return c.JSON(fiber.Map{"success": true, "message": "User berhasil dinonaktifkan"})
+}
+
+// ReactivateUserService (admin) mengaktifkan kembali akun yang disuspend atau dinonaktifkan
+func ReactivateUserService(c *fiber.Ctx) error {
+	var req model.UserStatusRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"success": false, "message": "Request body tidak valid", "error": err.Error()})
+		}
+	}
+
+	user, actorID, err := statusTarget(c)
+	if user == nil {
+		return err
+	}
+
+	if user.Status == "" || user.Status == model.UserStatusActive {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "User sudah aktif"})
+	}
+
+	if err := setUserStatus(c.UserContext(), user, actorID, "reactivate", model.UserStatusActive, strings.TrimSpace(req.Reason), nil); err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mengaktifkan user", "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "message": "User berhasil diaktifkan kembali"})
+}
+
+// GetUserStatusEventsService (admin) menampilkan riwayat perubahan status akun user
+func GetUserStatusEventsService(c *fiber.Ctx) error {
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"success": false, "message": "User ID tidak valid"})
+	}
+
+	events, err := userStatusRepo.GetStatusEvents(c.UserContext(), id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"success": false, "message": "Gagal mengambil riwayat status user", "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "message": "Riwayat status user berhasil diambil", "data": events})
+}
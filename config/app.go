@@ -28,22 +28,55 @@ package config
 
 import (
 	// "database/sql"
+	"strings"
+
+	"hello-fiber/app/repository"
+	"hello-fiber/app/service"
 	"hello-fiber/route"
 	"hello-fiber/middleware"
 	"github.com/gofiber/fiber/v2"
 	"hello-fiber/database"  // Mengimpor package database
+	"hello-fiber/utils"
 )
 
 func NewApp() *fiber.App {
 	mongoClient := database.ConnectMongoDB()
 	_ = mongoClient // Simpan reference jika diperlukan
 
+	if err := utils.LoadJWTKeys(); err != nil {
+		utils.Fatal("Error loading JWT keys", "error", err)
+	}
+
+	if err := utils.LoadPasswordHashers(); err != nil {
+		utils.Fatal("Error loading password hashing config", "error", err)
+	}
+
+	if err := repository.EnsureIndexes(); err != nil {
+		utils.Fatal("Error creating MongoDB indexes", "error", err)
+	}
+
+	if err := repository.RunMigrations(); err != nil {
+		utils.Fatal("Error running MongoDB migrations", "error", err)
+	}
+
+	if err := service.StartRoleCache(); err != nil {
+		utils.Fatal("Error loading role cache", "error", err)
+	}
+
 	// Initialize the Fiber application
 	app := fiber.New(fiber.Config{
 		BodyLimit: 2 * 1024 * 1024, // Set body limit to 2MB for file uploads
+		// c.IP() dipakai sebagai key lockout login dan batas reset password. Header proxy hanya
+		// dipercaya jika request datang dari TRUSTED_PROXIES, selain itu dipakai IP koneksi.
+		ProxyHeader:             utils.GetEnv("PROXY_HEADER", fiber.HeaderXForwardedFor),
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          trustedProxies(),
 	})
 
 	// Middleware
+	app.Use(middleware.RequestIDMiddleware())
+	app.Use(middleware.TracingMiddleware())
+	app.Use(middleware.MetricsMiddleware())
 	app.Use(middleware.LoggerMiddleware)
 
 	app.Static("/file", "./uploads")
@@ -52,4 +85,15 @@ func NewApp() *fiber.App {
 	route.SetupRoutes(app, nil) // Pass nil untuk db karena sudah global di database.MongoDB
 
 	return app
-}
\ No newline at end of file
+}
+
+// trustedProxies membaca TRUSTED_PROXIES (IP atau CIDR dipisah koma), kosong berarti tidak ada proxy
+func trustedProxies() []string {
+	proxies := []string{}
+	for _, p := range strings.Split(utils.GetEnv("TRUSTED_PROXIES", ""), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies
+}
@@ -2,8 +2,11 @@ package database
 
 import (
 	"database/sql"
-	"log"
+	"log/slog"
 	"os"
+
+	"hello-fiber/utils"
+
 	_ "github.com/lib/pq" // PostgreSQL driver
 )
 
@@ -12,14 +15,14 @@ var DB *sql.DB
 func ConnectDB() *sql.DB {
 	dsn := os.Getenv("DB_DSN") // Ambil nilai dari .env
 	if dsn == "" {
-		log.Fatal("DB_DSN environment variable is missing")
+		utils.Fatal("DB_DSN environment variable is missing")
 	}
 
 	// Jangan tambahkan sslmode=disable lagi di sini!
 	db, err := sql.Open("postgres", dsn)
 	if err != nil {
-		log.Fatal("Error connecting to database: ", err)
+		utils.Fatal("Error connecting to database", "error", err)
 	}
-	log.Println("Connected to database successfully")
+	slog.Info("Connected to database successfully")
 	return db
 }
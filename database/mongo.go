@@ -2,10 +2,12 @@ package database
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"os"
 	"time"
 
+	"hello-fiber/utils"
+
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
@@ -17,13 +19,15 @@ var MongoDB *mongo.Database
 func ConnectMongoDB() *mongo.Client {
 	mongoURI := os.Getenv("MONGO_URI")
 	if mongoURI == "" {
-		log.Fatal("MONGO_URI environment variable is missing")
+		utils.Fatal("MONGO_URI environment variable is missing")
 	}
 
-	opts := options.Client().ApplyURI(mongoURI)
+	opts := options.Client().ApplyURI(mongoURI).
+		SetMonitor(utils.CombineCommandMonitors(utils.MongoTracingMonitor(), utils.MongoCommandMonitor())).
+		SetPoolMonitor(utils.MongoPoolMonitor())
 	client, err := mongo.Connect(opts)
 	if err != nil {
-		log.Fatal("Error connecting to MongoDB: ", err)
+		utils.Fatal("Error connecting to MongoDB", "error", err)
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -31,10 +35,10 @@ func ConnectMongoDB() *mongo.Client {
 
 	err = client.Ping(ctx, nil)
 	if err != nil {
-		log.Fatal("Error pinging MongoDB: ", err)
+		utils.Fatal("Error pinging MongoDB", "error", err)
 	}
 
-	log.Println("Connected to MongoDB successfully")
+	slog.Info("Connected to MongoDB successfully")
 	MongoClient = client
 	MongoDB = client.Database(os.Getenv("MONGO_DB_NAME"))
 	return client
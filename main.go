@@ -1,29 +1,49 @@
 package main
 
 import (
-    "log"
+    "context"
+    "log/slog"
+    "time"
 
     "github.com/joho/godotenv"
 
     "hello-fiber/config"
     "hello-fiber/database"
+    "hello-fiber/utils"
 )
 
 func main() {
-    // load .env
-    if err := godotenv.Load(); err != nil {
-        log.Println("Warning: .env not loaded:", err)
+    // load .env, logger dibuat setelahnya supaya LOG_FORMAT dan LOG_LEVEL dari .env terbaca
+    envErr := godotenv.Load()
+    utils.InitLogger()
+    if envErr != nil {
+        slog.Warn("Warning: .env not loaded", "error", envErr)
     }
 
+    shutdownTracer, err := utils.InitTracer()
+    if err != nil {
+        utils.Fatal("Error initializing tracer", "error", err)
+    }
+    // kirim span yang masih di buffer exporter sebelum program keluar
+    defer func() {
+        ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+        defer cancel()
+        if err := shutdownTracer(ctx); err != nil {
+            slog.Error("Error shutting down tracer", "error", err)
+        }
+    }()
+
     // NewApp will call ConnectMongoDB internally
     app := config.NewApp()
 
     // disconnect saat program keluar (DisconnectMongoDB harus aman dipanggil jika belum terhubung)
     defer func() {
         if err := database.DisconnectMongoDB(); err != nil {
-            log.Println("Error disconnecting from MongoDB:", err)
+            slog.Error("Error disconnecting from MongoDB", "error", err)
         }
     }()
 
-    log.Fatal(app.Listen(":3000"))
+    if err := app.Listen(":3000"); err != nil {
+        utils.Fatal("Server stopped", "error", err)
+    }
 }
\ No newline at end of file
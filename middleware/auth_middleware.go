@@ -1,25 +1,29 @@
 package middleware
 
 import (
-	"context"
-	"fmt"
+	"errors"
 	"strings"
-	"time"
 
 	"hello-fiber/app/model"
-	"hello-fiber/database"
+	"hello-fiber/app/repository"
+	"hello-fiber/app/service"
 	"hello-fiber/utils"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
-// JWTMiddleware validates JWT token and stores claims in context
+// JWTMiddleware validates JWT token and stores claims in context.
+// Request tanpa header Authorization boleh memakai api key lewat header service.APIKeyHeader().
 func JWTMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
+		if authHeader == "" {
+			if apiKey := c.Get(service.APIKeyHeader()); apiKey != "" {
+				return apiKeyAuth(c, apiKey)
+			}
+		}
 		if authHeader == "" {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": "Authorization header required",
@@ -38,97 +42,181 @@ func JWTMiddleware() fiber.Handler {
 			})
 		}
 
-		token, err := jwt.ParseWithClaims(tokenString, &utils.Claims{}, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrTokenUnverifiable
-			}
-			return utils.GetJWTSecret(), nil
-		})
+		token, err := utils.ParseToken(tokenString, &utils.Claims{})
 		if err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid or expired token", "detail": err.Error()})
 		}
 
 		claims, ok := token.Claims.(*utils.Claims)
-		if !ok || !token.Valid {
+		if !ok || !token.Valid || !claims.IsAccessToken() {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token claims"})
 		}
 
+		revoked, err := service.IsTokenRevoked(c.UserContext(), claims)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to verify token status"})
+		}
+		if revoked {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Token has been revoked"})
+		}
+
+		blocked, err := service.IsUserBlocked(c.UserContext(), claims.UserID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to verify account status"})
+		}
+		if blocked {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Account is suspended or deactivated"})
+		}
+
+		// Token impersonasi ikut tidak berlaku jika akun admin-nya disuspend
+		if claims.IsImpersonation() {
+			blocked, err := service.IsUserBlocked(c.UserContext(), claims.Actor.Subject)
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to verify account status"})
+			}
+			if blocked {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Impersonating account is not active"})
+			}
+			c.Locals("impersonator_id", claims.Actor.Subject)
+			c.Locals("impersonator_email", claims.Actor.Email)
+		}
+
 		c.Locals("user_id", claims.UserID)
 		c.Locals("email", claims.Email)
 		c.Locals("role_id", claims.RoleID) // Now storing as string (hex format)
+		c.Locals("jti", claims.ID)
+		c.Locals("sid", claims.SessionID)
+		c.Locals("email_verified", !claims.Unverified)
+		c.Locals("mfa", claims.HasAMR(utils.AMROTP))
+		if claims.ExpiresAt != nil {
+			c.Locals("token_expires_at", claims.ExpiresAt.Time)
+		}
 
 		return c.Next()
 	}
 }
 
-// AdminOnlyMiddleware restricts access to admin users only
-func AdminOnlyMiddleware() fiber.Handler {
+// apiKeyAuth mengautentikasi request memakai api key. Request api key tidak terikat ke user,
+// sehingga user_id tidak diisi dan role efektif key langsung disimpan di locals "role".
+func apiKeyAuth(c *fiber.Ctx, apiKey string) error {
+	key, role, err := service.AuthenticateAPIKey(c.UserContext(), apiKey)
+	if errors.Is(err, service.ErrAPIKeyOwnerNotAllowed) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "API key owner is not active or no longer has the key's permissions"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid or expired API key"})
+	}
+
+	c.Locals("api_key_id", key.ID.Hex())
+	c.Locals("role", role)
+	if key.RoleID != nil {
+		c.Locals("role_id", key.RoleID.Hex())
+	}
+	c.Locals("email_verified", true)
+	c.Locals("mfa", false)
+
+	return c.Next()
+}
+
+// VerifiedEmailMiddleware membatasi user yang emailnya belum diverifikasi sesuai EMAIL_VERIFICATION_POLICY.
+// Pada mode "limit" user tersebut hanya boleh melakukan request baca dan path di allowedPaths.
+// Path dicocokkan per segmen seperti route Fiber, jadi "/api/me/sessions/:id" cocok dengan
+// "/api/me/sessions/123", dan slash di akhir path diabaikan.
+func VerifiedEmailMiddleware(allowedPaths ...string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		roleIDStr, ok := c.Locals("role_id").(string)
-		if !ok {
-			fmt.Printf("[DEBUG] role_id not found in locals\n")
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied. Admin role required"})
+		verified, _ := c.Locals("email_verified").(bool)
+		if verified || service.EmailVerificationPolicy() == service.EmailVerificationOff {
+			return c.Next()
 		}
 
-		fmt.Printf("[DEBUG] roleIDStr from JWT: %s\n", roleIDStr)
-
-		roleID, err := bson.ObjectIDFromHex(roleIDStr)
-		if err != nil {
-			fmt.Printf("[DEBUG] Failed to convert roleIDStr to ObjectID: %v\n", err)
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied. Invalid role"})
+		if service.EmailVerificationPolicy() == service.EmailVerificationLimit {
+			if c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead {
+				return c.Next()
+			}
+			for _, p := range allowedPaths {
+				if matchPath(p, c.Path()) {
+					return c.Next()
+				}
+			}
 		}
 
-		// Get admin role from database
-		adminRoleColl := database.MongoDB.Collection("roles")
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Email not verified"})
+	}
+}
 
-		cursor, err := adminRoleColl.Find(ctx, bson.M{})
-		if err == nil {
-			defer cursor.Close(ctx)
-			var allRoles []model.Role
-			if err := cursor.All(ctx, &allRoles); err == nil {
-				fmt.Printf("[DEBUG] All roles in database:\n")
-				for _, r := range allRoles {
-					fmt.Printf("  - ID: %s, Role: %s\n", r.ID.Hex(), r.Role)
-				}
+// matchPath mencocokkan path request dengan pola route, segmen ":param" cocok dengan segmen apa pun
+func matchPath(pattern, path string) bool {
+	patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(patternParts) != len(pathParts) {
+		return false
+	}
+	for i, part := range patternParts {
+		if strings.HasPrefix(part, ":") {
+			if pathParts[i] == "" {
+				return false
 			}
+			continue
+		}
+		if !strings.EqualFold(part, pathParts[i]) {
+			return false
 		}
+	}
+	return true
+}
 
-		var adminRole model.Role
-		err = adminRoleColl.FindOne(ctx, bson.M{"role": "admin"}).Decode(&adminRole)
+// RequirePermission memastikan role user memiliki semua permission yang diminta
+func RequirePermission(permissions ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, err := service.CurrentRole(c)
 		if err != nil {
-			fmt.Printf("[DEBUG] Admin role not found with query {role: 'admin'}: %v\n", err)
-			// Try alternative query
-			err = adminRoleColl.FindOne(ctx, bson.M{"role": "Admin"}).Decode(&adminRole)
-			if err != nil {
-				fmt.Printf("[DEBUG] Admin role not found with query {role: 'Admin'}: %v\n", err)
-				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied. Admin role not found in database"})
-			}
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied. Invalid role"})
 		}
 
-		fmt.Printf("[DEBUG] User roleID: %s, Admin roleID: %s\n", roleID.Hex(), adminRole.ID.Hex())
+		for _, permission := range permissions {
+			if !role.HasPermission(permission) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied. Missing permission: " + permission})
+			}
+		}
 
-		if roleID != adminRole.ID {
-			fmt.Printf("[DEBUG] Role mismatch - User has: %s, Admin is: %s\n", roleID.Hex(), adminRole.ID.Hex())
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied. Admin role required"})
+		// Kebijakan REQUIRE_ADMIN_2FA: role dengan akses penuh wajib login dengan 2FA
+		if role.HasPermission(model.PermissionAll) && utils.GetEnv("REQUIRE_ADMIN_2FA", "false") == "true" {
+			if mfa, _ := c.Locals("mfa").(bool); !mfa {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Two-factor authentication required for admin access"})
+			}
 		}
 
-		fmt.Printf("[DEBUG] Admin verification passed!\n")
 		return c.Next()
 	}
 }
 
-// PekerjaanOwnerMiddlewareMongo memastikan user hanya boleh akses pekerjaan miliknya
-// Verifikasi dilakukan dengan membandingkan id, email, dan roleID dari JWT
-func PekerjaanOwnerMiddlewareMongo() fiber.Handler {
+// AdminOnlyMiddleware restricts access to roles with full access ("*" permission)
+func AdminOnlyMiddleware() fiber.Handler {
+	return RequirePermission(model.PermissionAll)
+}
+
+var (
+	userRepo      = repository.NewUserRepositoryMongo()
+	pekerjaanRepo = repository.NewPekerjaanAlumniRepositoryMongo()
+)
+
+// PekerjaanOwnerMiddlewareMongo memastikan user hanya boleh mengelola pekerjaan miliknya.
+// User dengan `permission` (mis. admin/staff) tetap punya akses penuh. User lain hanya lolos jika
+// pekerjaan pada :id milik alumni yang tertaut ke akunnya (user.alumni_id). Untuk route tanpa :id
+// (create), alumni_id user disimpan di locals "owner_alumni_id" dan dipaksakan oleh service.
+func PekerjaanOwnerMiddlewareMongo(permission string) fiber.Handler {
+	requirePermission := RequirePermission(permission)
+
 	return func(c *fiber.Ctx) error {
-		userIDStr, ok := c.Locals("user_id").(string)
-		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		role, err := service.CurrentRole(c)
+		if err != nil {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied. Invalid role"})
+		}
+		if role.HasPermission(permission) {
+			return requirePermission(c)
 		}
 
-		email, ok := c.Locals("email").(string)
+		userIDStr, ok := c.Locals("user_id").(string)
 		if !ok {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
 		}
@@ -138,16 +226,6 @@ func PekerjaanOwnerMiddlewareMongo() fiber.Handler {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
 		}
 
-		pekerjaanIDStr := c.Params("id")
-		if pekerjaanIDStr == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid pekerjaan id"})
-		}
-
-		pekerjaanID, err := bson.ObjectIDFromHex(pekerjaanIDStr)
-		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid pekerjaan id format"})
-		}
-
 		userID, err := bson.ObjectIDFromHex(userIDStr)
 		if err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user id"})
@@ -158,36 +236,45 @@ func PekerjaanOwnerMiddlewareMongo() fiber.Handler {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid role id"})
 		}
 
-		coll := database.MongoDB.Collection("pekerjaan_alumni")
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-
-		filter := bson.M{"_id": pekerjaanID}
-		var pekerjaan struct {
-			AlumniID bson.ObjectID `bson:"alumni_id"`
-		}
-		if err := coll.FindOne(ctx, filter).Decode(&pekerjaan); err != nil {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Pekerjaan alumni tidak ditemukan"})
+		userDoc, err := userRepo.GetUserByID(c.UserContext(), userID)
+		if err != nil {
+			if errors.Is(err, repository.ErrUserNotFound) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to verify ownership"})
 		}
 
-		usersColl := database.MongoDB.Collection("users")
-		var userDoc model.User
-		if err := usersColl.FindOne(ctx, bson.M{"_id": userID}).Decode(&userDoc); err != nil {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		// RoleID dari JWT harus sama dengan role user di database (email tidak dibandingkan
+		// karena user boleh menggantinya sendiri lewat /api/me)
+		if userDoc.RoleID != roleID {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden: JWT claims do not match user data"})
 		}
 
-		// 1. User ID from JWT matches user in database
-		// 2. Email from JWT matches user email in database
-		// 3. RoleID from JWT matches user role in database
-		// 4. Alumni ID from pekerjaan matches user's alumni_id
-		if userDoc.ID != userID || userDoc.Email != email || userDoc.RoleID != roleID { // Now comparing ObjectID to ObjectID
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden: JWT claims do not match user data"})
+		if userDoc.AlumniID == nil || userDoc.AlumniID.IsZero() {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied. Missing permission: " + permission})
 		}
 
-		if pekerjaan.AlumniID != userDoc.ID {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden: You can only access your own data"})
+		pekerjaanIDStr := c.Params("id")
+		if pekerjaanIDStr != "" {
+			pekerjaanID, err := bson.ObjectIDFromHex(pekerjaanIDStr)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid pekerjaan id format"})
+			}
+
+			ownerID, err := pekerjaanRepo.GetPekerjaanOwnerID(c.UserContext(), pekerjaanID)
+			if err != nil {
+				if errors.Is(err, repository.ErrPekerjaanNotFound) {
+					return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Pekerjaan alumni tidak ditemukan"})
+				}
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to verify ownership"})
+			}
+
+			if ownerID != *userDoc.AlumniID {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden: You can only access your own data"})
+			}
 		}
 
+		c.Locals("owner_alumni_id", *userDoc.AlumniID)
 		return c.Next()
 	}
 }
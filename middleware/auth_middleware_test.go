@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"hello-fiber/app/model"
+	"hello-fiber/app/repository"
+	"hello-fiber/app/service"
+	"hello-fiber/database"
+	"hello-fiber/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var benchMongoOnce sync.Once
+
+// benchRole membuat role sementara di database benchmark (MONGO_URI + MONGO_TEST_DB_NAME).
+// Benchmark dilewati jika MONGO_URI tidak diisi karena perbandingan butuh MongoDB sungguhan.
+func benchRole(b *testing.B) *model.Role {
+	if os.Getenv("MONGO_URI") == "" {
+		b.Skip("MONGO_URI tidak diisi, benchmark role cache butuh MongoDB")
+	}
+	benchMongoOnce.Do(func() {
+		os.Setenv("MONGO_DB_NAME", utils.GetEnv("MONGO_TEST_DB_NAME", "hello_fiber_test"))
+		database.ConnectMongoDB()
+		if err := service.StartRoleCache(); err != nil {
+			b.Fatal(err)
+		}
+	})
+
+	roles := repository.NewRoleRepositoryMongo()
+	id, err := roles.CreateRole(context.Background(), model.CreateRoleRequest{
+		Role:        "bench_" + bson.NewObjectID().Hex(),
+		Permissions: []string{model.PermAlumniRead},
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { _ = roles.DeleteRole(context.Background(), id) })
+
+	role, err := roles.GetRoleByID(context.Background(), id)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return role
+}
+
+// requirePermissionPerRequest adalah RequirePermission sebelum ada role cache:
+// role dibaca dari collection roles di setiap request
+func requirePermissionPerRequest(permission string) fiber.Handler {
+	roles := repository.NewRoleRepositoryMongo()
+	return func(c *fiber.Ctx) error {
+		roleID, err := bson.ObjectIDFromHex(c.Locals("role_id").(string))
+		if err != nil {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied. Invalid role"})
+		}
+		role, err := roles.GetRoleByID(c.UserContext(), roleID)
+		if err != nil {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied. Invalid role"})
+		}
+		if !role.HasPermission(permission) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied. Missing permission: " + permission})
+		}
+		return c.Next()
+	}
+}
+
+func benchmarkPermission(b *testing.B, roleID string, check fiber.Handler) {
+	app := fiber.New()
+	app.Get("/",
+		func(c *fiber.Ctx) error {
+			c.Locals("role_id", roleID)
+			return c.Next()
+		},
+		check,
+		func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) },
+	)
+	handler := app.Handler()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var ctx fasthttp.RequestCtx
+		ctx.Request.SetRequestURI("/")
+		handler(&ctx)
+		if ctx.Response.StatusCode() != fiber.StatusOK {
+			b.Fatalf("status %d: %s", ctx.Response.StatusCode(), ctx.Response.Body())
+		}
+	}
+}
+
+// BenchmarkRequirePermission membandingkan pengecekan permission lewat role cache dengan
+// query role per request. Jalankan dengan:
+//
+//	MONGO_URI=mongodb://localhost:27017 go test ./middleware -run ^$ -bench RequirePermission
+func BenchmarkRequirePermission(b *testing.B) {
+	b.Run("cached", func(b *testing.B) {
+		role := benchRole(b)
+		benchmarkPermission(b, role.ID.Hex(), RequirePermission(model.PermAlumniRead))
+	})
+	b.Run("per_request_lookup", func(b *testing.B) {
+		role := benchRole(b)
+		benchmarkPermission(b, role.ID.Hex(), requirePermissionPerRequest(model.PermAlumniRead))
+	})
+}
+
+func TestVerifiedEmailMiddlewareAllowedPaths(t *testing.T) {
+	t.Setenv("EMAIL_VERIFICATION_POLICY", "limit")
+
+	app := fiber.New()
+	api := app.Group("/api", func(c *fiber.Ctx) error {
+		c.Locals("email_verified", false)
+		return c.Next()
+	}, VerifiedEmailMiddleware("/api/me", "/api/me/sessions/:id"))
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	api.Patch("/me", ok)
+	api.Delete("/me/sessions/:id", ok)
+	api.Delete("/me/sessions", ok)
+	api.Post("/alumni", ok)
+	api.Get("/alumni", ok)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		want   int
+	}{
+		{name: "path persis", method: "PATCH", target: "/api/me", want: fiber.StatusOK},
+		{name: "slash di akhir", method: "PATCH", target: "/api/me/", want: fiber.StatusOK},
+		{name: "route berparameter", method: "DELETE", target: "/api/me/sessions/abc123", want: fiber.StatusOK},
+		{name: "path tidak diizinkan", method: "DELETE", target: "/api/me/sessions", want: fiber.StatusForbidden},
+		{name: "tulis ke path lain", method: "POST", target: "/api/alumni", want: fiber.StatusForbidden},
+		{name: "request baca", method: "GET", target: "/api/alumni", want: fiber.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest(tt.method, tt.target, nil), -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("%s %s: status %d, want %d", tt.method, tt.target, resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"hello-fiber/app/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ImpersonationMiddleware menandai response selama impersonasi dan mencatat setiap request
+// atas nama admin yang sebenarnya. Response JSON berbentuk object mendapat field "impersonation"
+// dan semua response mendapat header X-Impersonated-By.
+func ImpersonationMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !service.IsImpersonating(c) {
+			return c.Next()
+		}
+
+		adminID, _ := c.Locals("impersonator_id").(string)
+		c.Set("X-Impersonated-By", adminID)
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			if fe, ok := err.(*fiber.Error); ok {
+				status = fe.Code
+			} else {
+				status = fiber.StatusInternalServerError
+			}
+		} else {
+			injectJSONField(c, "impersonation", service.ImpersonationBanner(c))
+		}
+		service.RecordImpersonatedRequest(c, status)
+
+		return err
+	}
+}
+
+// injectJSONField menambahkan field key di awal body JSON berbentuk object.
+// Body lain (array, teks, file) dibiarkan apa adanya.
+func injectJSONField(c *fiber.Ctx, key string, value interface{}) {
+	if !strings.HasPrefix(string(c.Response().Header.ContentType()), fiber.MIMEApplicationJSON) {
+		return
+	}
+	body := bytes.TrimSpace(c.Response().Body())
+	if len(body) < 2 || body[0] != '{' {
+		return
+	}
+
+	name, err := json.Marshal(key)
+	if err != nil {
+		return
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+
+	var out bytes.Buffer
+	out.WriteByte('{')
+	out.Write(name)
+	out.WriteByte(':')
+	out.Write(encoded)
+	if rest := bytes.TrimSpace(body[1:]); len(rest) > 0 && rest[0] != '}' {
+		out.WriteByte(',')
+	}
+	out.Write(body[1:])
+	c.Response().SetBodyRaw(out.Bytes())
+}
+
+// DenyImpersonation menolak aksi sensitif (ganti password, 2FA, penghapusan, dll.) selama impersonasi
+func DenyImpersonation() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if service.IsImpersonating(c) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Action not allowed while impersonating"})
+		}
+		return c.Next()
+	}
+}
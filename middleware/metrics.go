@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net"
+	"strings"
+	"time"
+
+	"hello-fiber/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsMiddleware mencatat jumlah dan latensi request per route template dan status.
+// Dipasang sebelum LoggerMiddleware supaya status yang dicatat sudah melewati error handler.
+func MetricsMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			if fe, ok := err.(*fiber.Error); ok {
+				status = fe.Code
+			}
+		}
+
+		utils.ObserveHTTPRequest(c.Method(), routeLabel(c, status), status, time.Since(start).Seconds())
+		return err
+	}
+}
+
+// routeLabel mengembalikan template route request. Route().Path selalu template yang terdaftar;
+// request yang tidak cocok dengan route manapun berhenti di middleware app.Use ("/") dan
+// dikelompokkan jadi satu label.
+func routeLabel(c *fiber.Ctx, status int) string {
+	route := c.Route().Path
+	if status == fiber.StatusNotFound && route == "/" && c.Path() != "/" {
+		return "unmatched"
+	}
+	return route
+}
+
+// MetricsHandler menyajikan metrik Prometheus. Jika METRICS_TOKEN diisi, scraper wajib
+// mengirim header Authorization: Bearer <token>. Tanpa METRICS_TOKEN endpoint hanya bisa
+// diakses dari loopback (127.0.0.1/::1), kecuali METRICS_PUBLIC=true.
+func MetricsHandler() fiber.Handler {
+	handler := adaptor.HTTPHandler(promhttp.Handler())
+
+	return func(c *fiber.Ctx) error {
+		if token := utils.GetEnv("METRICS_TOKEN", ""); token != "" {
+			given := strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid metrics token"})
+			}
+		} else if utils.GetEnv("METRICS_PUBLIC", "false") != "true" && !isLoopback(c.IP()) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Metrics are only available from localhost or with METRICS_TOKEN"})
+		}
+		return handler(c)
+	}
+}
+
+// isLoopback mengecek IP client (c.IP(), header proxy hanya dipakai dari TRUSTED_PROXIES)
+func isLoopback(ip string) bool {
+	parsed := net.ParseIP(ip)
+	return parsed != nil && parsed.IsLoopback()
+}
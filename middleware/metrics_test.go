@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestMetricsHandlerAccess(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      string
+		public     string
+		remoteIP   string
+		authHeader string
+		want       int
+	}{
+		{name: "tanpa token dari localhost", remoteIP: "127.0.0.1", want: fiber.StatusOK},
+		{name: "tanpa token dari localhost IPv6", remoteIP: "::1", want: fiber.StatusOK},
+		{name: "tanpa token dari luar", remoteIP: "203.0.113.7", want: fiber.StatusForbidden},
+		{name: "METRICS_PUBLIC dari luar", public: "true", remoteIP: "203.0.113.7", want: fiber.StatusOK},
+		{name: "token benar", token: "rahasia", remoteIP: "203.0.113.7", authHeader: "Bearer rahasia", want: fiber.StatusOK},
+		{name: "token salah", token: "rahasia", remoteIP: "203.0.113.7", authHeader: "Bearer salah", want: fiber.StatusUnauthorized},
+		{name: "token wajib walau dari localhost", token: "rahasia", remoteIP: "127.0.0.1", want: fiber.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("METRICS_TOKEN", tt.token)
+			t.Setenv("METRICS_PUBLIC", tt.public)
+
+			app := fiber.New()
+			app.Get("/metrics", MetricsHandler())
+
+			var req fasthttp.Request
+			req.SetRequestURI("/metrics")
+			if tt.authHeader != "" {
+				req.Header.Set(fiber.HeaderAuthorization, tt.authHeader)
+			}
+			var ctx fasthttp.RequestCtx
+			ctx.Init(&req, &net.TCPAddr{IP: net.ParseIP(tt.remoteIP)}, nil)
+			app.Handler()(&ctx)
+
+			if got := ctx.Response.StatusCode(); got != tt.want {
+				t.Errorf("status %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"net/http"
+
+	"hello-fiber/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceIDHeader berisi trace ID request supaya client bisa melaporkannya saat terjadi error
+const TraceIDHeader = "X-Trace-ID"
+
+// TracingMiddleware membuat span server untuk setiap request. Konteks trace W3C dari header
+// traceparent/tracestate dipakai sebagai parent, dan context span disimpan di c.UserContext()
+// supaya query MongoDB dan request keluar menjadi child span. Response JSON error (status >= 400)
+// mendapat field "trace_id". Dipasang sebelum LoggerMiddleware supaya access log membawa trace_id.
+func TracingMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), requestHeaderCarrier{c})
+		ctx, span := utils.Tracer().Start(ctx, c.Method(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Method()),
+				semconv.URLPath(c.Path()),
+				semconv.URLScheme(c.Protocol()),
+				semconv.ClientAddress(c.IP()),
+				semconv.UserAgentOriginal(c.Get(fiber.HeaderUserAgent)),
+			),
+		)
+		defer span.End()
+
+		c.SetUserContext(ctx)
+		traceID := utils.TraceID(ctx)
+		if traceID != "" {
+			c.Set(TraceIDHeader, traceID)
+		}
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			if fe, ok := err.(*fiber.Error); ok {
+				status = fe.Code
+			}
+			span.RecordError(err)
+		}
+
+		route := routeLabel(c, status)
+		span.SetName(c.Method() + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route), semconv.HTTPResponseStatusCode(status))
+		if userID, ok := c.Locals("user_id").(string); ok && userID != "" {
+			span.SetAttributes(semconv.UserID(userID))
+		}
+		// Status 4xx adalah kesalahan client, span server hanya ditandai error untuk 5xx
+		if status >= 500 {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		if err == nil && status >= 400 && traceID != "" {
+			injectJSONField(c, "trace_id", traceID)
+		}
+
+		return err
+	}
+}
+
+// requestHeaderCarrier membaca header request Fiber untuk propagator OpenTelemetry
+type requestHeaderCarrier struct {
+	c *fiber.Ctx
+}
+
+func (h requestHeaderCarrier) Get(key string) string {
+	return h.c.Get(key)
+}
+
+func (h requestHeaderCarrier) Set(key, value string) {
+	h.c.Request().Header.Set(key, value)
+}
+
+func (h requestHeaderCarrier) Keys() []string {
+	keys := []string{}
+	h.c.Request().Header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
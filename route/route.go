@@ -1,6 +1,7 @@
 package route
 
 import (
+	"hello-fiber/app/model"
 	"hello-fiber/app/repository"
 	"hello-fiber/app/service"
 	"github.com/gofiber/fiber/v2"
@@ -8,6 +9,11 @@ import (
 )
 
 func SetupRoutes(app *fiber.App, db interface{}) {
+	app.Get("/.well-known/jwks.json", func(c *fiber.Ctx) error {
+		return service.JWKSService(c)
+	})
+	app.Get("/metrics", middleware.MetricsHandler())
+
 	api := app.Group("/api")
 
 	api.Post("/register", func(c *fiber.Ctx) error {
@@ -18,75 +24,235 @@ func SetupRoutes(app *fiber.App, db interface{}) {
 		return service.LoginService(c)
 	})
 
-	protected := api.Group("/", middleware.JWTMiddleware())
+	api.Post("/login/2fa", func(c *fiber.Ctx) error {
+		return service.LoginTwoFactorService(c)
+	})
+
+	api.Post("/token/refresh", func(c *fiber.Ctx) error {
+		return service.RefreshTokenService(c)
+	})
+
+	api.Post("/password/forgot", func(c *fiber.Ctx) error {
+		return service.ForgotPasswordService(c)
+	})
+
+	api.Post("/password/reset", func(c *fiber.Ctx) error {
+		return service.ResetPasswordService(c)
+	})
+
+	api.Get("/auth/oidc/login", func(c *fiber.Ctx) error {
+		return service.OIDCLoginService(c)
+	})
+
+	api.Get("/auth/oidc/callback", func(c *fiber.Ctx) error {
+		return service.OIDCCallbackService(c)
+	})
+
+	api.Get("/verify-email", func(c *fiber.Ctx) error {
+		return service.VerifyEmailService(c)
+	})
+
+	api.Post("/verify-email/resend", func(c *fiber.Ctx) error {
+		return service.ResendVerificationService(c)
+	})
+
+	// /api/me dan /api/me/password tetap boleh diakses user yang belum verifikasi (mis. untuk memperbaiki email yang salah ketik)
+	// Selama impersonasi, aksi sensitif ditolak dengan middleware.DenyImpersonation()
+	protected := api.Group("/", middleware.JWTMiddleware(), middleware.ImpersonationMiddleware(), middleware.VerifiedEmailMiddleware("/api/logout", "/api/me", "/api/me/password", "/api/me/sessions", "/api/me/sessions/:id"))
+
+	protected.Post("/logout", func(c *fiber.Ctx) error {
+		return service.LogoutService(c)
+	})
+
+	me := protected.Group("/me")
+	me.Get("/", func(c *fiber.Ctx) error {
+		return service.GetMeService(c)
+	})
+	me.Patch("/", middleware.DenyImpersonation(), func(c *fiber.Ctx) error {
+		return service.UpdateMeService(c)
+	})
+	me.Put("/password", middleware.DenyImpersonation(), func(c *fiber.Ctx) error {
+		return service.ChangeMyPasswordService(c)
+	})
+	me.Get("/security-events", func(c *fiber.Ctx) error {
+		return service.GetMySecurityEventsService(c)
+	})
+	me.Get("/sessions", func(c *fiber.Ctx) error {
+		return service.GetMySessionsService(c)
+	})
+	me.Delete("/sessions", middleware.DenyImpersonation(), func(c *fiber.Ctx) error {
+		return service.RevokeOtherSessionsService(c)
+	})
+	me.Delete("/sessions/:id", middleware.DenyImpersonation(), func(c *fiber.Ctx) error {
+		return service.RevokeMySessionService(c)
+	})
+
+	twoFactor := protected.Group("/2fa", middleware.DenyImpersonation())
+	twoFactor.Post("/setup", func(c *fiber.Ctx) error {
+		return service.TwoFactorSetupService(c)
+	})
+	twoFactor.Post("/enable", func(c *fiber.Ctx) error {
+		return service.TwoFactorEnableService(c)
+	})
+	twoFactor.Post("/disable", func(c *fiber.Ctx) error {
+		return service.TwoFactorDisableService(c)
+	})
+	twoFactor.Post("/recovery-codes", func(c *fiber.Ctx) error {
+		return service.RegenerateRecoveryCodesService(c)
+	})
 
 	users := protected.Group("/users")
-	users.Get("/", middleware.AdminOnlyMiddleware(), func(c *fiber.Ctx) error {
+	users.Get("/", middleware.RequirePermission(model.PermUsersRead), func(c *fiber.Ctx) error {
 		return service.GetAllUsersService(c)
 	})
-	users.Post("/", middleware.AdminOnlyMiddleware(), func(c *fiber.Ctx) error {
+	users.Post("/", middleware.RequirePermission(model.PermUsersWrite), func(c *fiber.Ctx) error {
 		return service.CreateUserAdmin(c)
 	})
-	users.Put("/:id", middleware.AdminOnlyMiddleware(), func(c *fiber.Ctx) error {
+	users.Put("/:id", middleware.RequirePermission(model.PermUsersWrite), func(c *fiber.Ctx) error {
 		return service.UpdateUserService(c)
 	})
-	users.Delete("/:id", middleware.AdminOnlyMiddleware(), func(c *fiber.Ctx) error {
+	users.Delete("/:id", middleware.DenyImpersonation(), middleware.RequirePermission(model.PermUsersDelete), func(c *fiber.Ctx) error {
 		return service.DeleteUserService(c)
 	})
+	users.Post("/:id/revoke-tokens", middleware.RequirePermission(model.PermUsersWrite), func(c *fiber.Ctx) error {
+		return service.RevokeUserTokensService(c)
+	})
+	users.Post("/:id/unlock", middleware.RequirePermission(model.PermUsersWrite), func(c *fiber.Ctx) error {
+		return service.UnlockUserService(c)
+	})
+	users.Post("/:id/suspend", middleware.RequirePermission(model.PermUsersWrite), func(c *fiber.Ctx) error {
+		return service.SuspendUserService(c)
+	})
+	users.Post("/:id/deactivate", middleware.RequirePermission(model.PermUsersWrite), func(c *fiber.Ctx) error {
+		return service.DeactivateUserService(c)
+	})
+	users.Post("/:id/reactivate", middleware.RequirePermission(model.PermUsersWrite), func(c *fiber.Ctx) error {
+		return service.ReactivateUserService(c)
+	})
+	users.Get("/:id/status-events", middleware.RequirePermission(model.PermUsersRead), func(c *fiber.Ctx) error {
+		return service.GetUserStatusEventsService(c)
+	})
+	users.Post("/:id/impersonate", middleware.DenyImpersonation(), middleware.RequirePermission(model.PermUsersImpersonate), func(c *fiber.Ctx) error {
+		return service.ImpersonateUserService(c)
+	})
+	users.Get("/:id/sessions", middleware.RequirePermission(model.PermUsersRead), func(c *fiber.Ctx) error {
+		return service.GetUserSessionsService(c)
+	})
+	users.Delete("/:id/sessions/:sid", middleware.RequirePermission(model.PermUsersWrite), func(c *fiber.Ctx) error {
+		return service.RevokeUserSessionService(c)
+	})
+
+	invitations := protected.Group("/invitations")
+	invitations.Post("/", middleware.RequirePermission(model.PermUsersWrite), func(c *fiber.Ctx) error {
+		return service.CreateInvitationService(c)
+	})
+	invitations.Get("/", middleware.RequirePermission(model.PermUsersWrite), func(c *fiber.Ctx) error {
+		return service.GetInvitationsService(c)
+	})
+	invitations.Post("/alumni", middleware.RequirePermission(model.PermUsersWrite, model.PermAlumniRead), func(c *fiber.Ctx) error {
+		return service.InviteAlumniService(c)
+	})
+	invitations.Post("/:id/resend", middleware.RequirePermission(model.PermUsersWrite), func(c *fiber.Ctx) error {
+		return service.ResendInvitationService(c)
+	})
+
+	roles := protected.Group("/roles", middleware.RequirePermission(model.PermRolesManage))
+	roles.Get("/", func(c *fiber.Ctx) error {
+		return service.GetAllRolesService(c)
+	})
+	roles.Get("/permissions", func(c *fiber.Ctx) error {
+		return service.GetPermissionsService(c)
+	})
+	roles.Get("/:id", func(c *fiber.Ctx) error {
+		return service.GetRoleByIDService(c)
+	})
+	roles.Post("/", func(c *fiber.Ctx) error {
+		return service.CreateRoleService(c)
+	})
+	roles.Put("/:id", func(c *fiber.Ctx) error {
+		return service.UpdateRoleService(c)
+	})
+	roles.Delete("/:id", func(c *fiber.Ctx) error {
+		return service.DeleteRoleService(c)
+	})
+	roles.Post("/:id/reassign", func(c *fiber.Ctx) error {
+		return service.ReassignRoleService(c)
+	})
+
+	// API key berlaku lebih lama dari token impersonasi sehingga tidak boleh dikelola selama impersonasi
+	apiKeys := protected.Group("/api-keys", middleware.DenyImpersonation(), middleware.RequirePermission(model.PermAPIKeysManage))
+	apiKeys.Get("/", func(c *fiber.Ctx) error {
+		return service.GetAllAPIKeysService(c)
+	})
+	apiKeys.Post("/", func(c *fiber.Ctx) error {
+		return service.CreateAPIKeyService(c)
+	})
+	apiKeys.Delete("/:id", func(c *fiber.Ctx) error {
+		return service.RevokeAPIKeyService(c)
+	})
+
+	security := protected.Group("/security")
+	security.Get("/lockouts", middleware.RequirePermission(model.PermSecurityRead), func(c *fiber.Ctx) error {
+		return service.GetLockoutEventsService(c)
+	})
+	security.Get("/events", middleware.RequirePermission(model.PermSecurityRead), func(c *fiber.Ctx) error {
+		return service.GetSecurityEventsService(c)
+	})
 
 	alumni := protected.Group("/alumni")
-	alumni.Get("/", func(c *fiber.Ctx) error {
+	alumni.Get("/", middleware.RequirePermission(model.PermAlumniRead), func(c *fiber.Ctx) error {
 		return service.GetAllAlumniService(c)
 	})
-	alumni.Get("/:id", func(c *fiber.Ctx) error {
+	alumni.Get("/:id", middleware.RequirePermission(model.PermAlumniRead), func(c *fiber.Ctx) error {
 		return service.GetAlumniByIDService(c)
 	})
-	alumni.Post("/", middleware.AdminOnlyMiddleware(), func(c *fiber.Ctx) error {
+	alumni.Post("/", middleware.RequirePermission(model.PermAlumniWrite), func(c *fiber.Ctx) error {
 		return service.CreateAlumniService(c)
 	})
-	alumni.Put("/:id", middleware.AdminOnlyMiddleware(), func(c *fiber.Ctx) error {
+	alumni.Put("/:id", middleware.RequirePermission(model.PermAlumniWrite), func(c *fiber.Ctx) error {
 		return service.UpdateAlumniService(c)
 	})
-	alumni.Delete("/:id", middleware.AdminOnlyMiddleware(), func(c *fiber.Ctx) error {
+	alumni.Delete("/:id", middleware.DenyImpersonation(), middleware.RequirePermission(model.PermAlumniDelete), func(c *fiber.Ctx) error {
 		return service.DeleteAlumniService(c)
 	})
 
 	pekerjaan := protected.Group("/pekerjaan")
 	
 	// Get routes
-	pekerjaan.Get("/", func(c *fiber.Ctx) error {
+	pekerjaan.Get("/", middleware.RequirePermission(model.PermPekerjaanRead), func(c *fiber.Ctx) error {
 		return service.GetAllPekerjaanAlumniService(c)
 	})
-	pekerjaan.Get("/trash", func(c *fiber.Ctx) error {
+	pekerjaan.Get("/trash", middleware.RequirePermission(model.PermPekerjaanTrashRead), func(c *fiber.Ctx) error {
 		return service.GetTrashedPekerjaanAlumniService(c)
 	})
-	pekerjaan.Get("/alumni/:alumni_id", func(c *fiber.Ctx) error {
+	pekerjaan.Get("/alumni/:alumni_id", middleware.RequirePermission(model.PermPekerjaanRead), func(c *fiber.Ctx) error {
 		return service.GetPekerjaanAlumniByAlumniIDService(c)
 	})
-	pekerjaan.Get("/:id", func(c *fiber.Ctx) error {
+	pekerjaan.Get("/:id", middleware.RequirePermission(model.PermPekerjaanRead), func(c *fiber.Ctx) error {
 		return service.GetPekerjaanAlumniByIDService(c)
 	})
 	
+	// Create/update/delete/restore: alumni boleh mengelola pekerjaan miliknya sendiri tanpa permission penuh
 	// Create route
-	pekerjaan.Post("/", middleware.AdminOnlyMiddleware(), func(c *fiber.Ctx) error {
+	pekerjaan.Post("/", middleware.PekerjaanOwnerMiddlewareMongo(model.PermPekerjaanWrite), func(c *fiber.Ctx) error {
 		return service.CreatePekerjaanAlumniService(c)
 	})
 	
 	// Update routes
-	pekerjaan.Put("/:id", middleware.AdminOnlyMiddleware(), func(c *fiber.Ctx) error {
+	pekerjaan.Put("/:id", middleware.PekerjaanOwnerMiddlewareMongo(model.PermPekerjaanWrite), func(c *fiber.Ctx) error {
 		return service.UpdatePekerjaanAlumniService(c)
 	})
 	
 	// Delete routes (soft delete)
-	pekerjaan.Delete("/:id", middleware.AdminOnlyMiddleware(), func(c *fiber.Ctx) error {
+	pekerjaan.Delete("/:id", middleware.DenyImpersonation(), middleware.PekerjaanOwnerMiddlewareMongo(model.PermPekerjaanDelete), func(c *fiber.Ctx) error {
 		return service.DeletePekerjaanAlumniService(c)
 	})
 	
 	// Trash management routes
-	pekerjaan.Delete("/trash/:id", middleware.AdminOnlyMiddleware(), func(c *fiber.Ctx) error {
+	pekerjaan.Delete("/trash/:id", middleware.DenyImpersonation(), middleware.RequirePermission(model.PermPekerjaanTrashPurge), func(c *fiber.Ctx) error {
 		return service.HardDeleteTrashedPekerjaanAlumniService(c)
 	})
-	pekerjaan.Put("/trash/:id/restore", middleware.AdminOnlyMiddleware(), func(c *fiber.Ctx) error {
+	pekerjaan.Put("/trash/:id/restore", middleware.PekerjaanOwnerMiddlewareMongo(model.PermPekerjaanTrashRestore), func(c *fiber.Ctx) error {
 		return service.RestoreTrashedPekerjaanAlumniService(c)
 	})
 
@@ -94,19 +260,19 @@ func SetupRoutes(app *fiber.App, db interface{}) {
 	fileUploadService := service.NewFileUploadService(fileUploadRepo, "./uploads")
 
 	files := protected.Group("/files")
-	files.Get("/", func(c *fiber.Ctx) error {
+	files.Get("/", middleware.RequirePermission(model.PermFilesRead), func(c *fiber.Ctx) error {
 		return fileUploadService.GetAllFiles(c)
 	})
-	files.Get("/:id", func(c *fiber.Ctx) error {
+	files.Get("/:id", middleware.RequirePermission(model.PermFilesRead), func(c *fiber.Ctx) error {
 		return fileUploadService.GetFileByID(c)
 	})
-	files.Post("/foto", func(c *fiber.Ctx) error {
+	files.Post("/foto", middleware.RequirePermission(model.PermFilesUpload), func(c *fiber.Ctx) error {
 		return fileUploadService.UploadFoto(c)
 	})
-	files.Post("/sertifikat", func(c *fiber.Ctx) error {
+	files.Post("/sertifikat", middleware.RequirePermission(model.PermFilesUpload), func(c *fiber.Ctx) error {
 		return fileUploadService.UploadSertifikat(c)
 	})
-	files.Delete("/:id", func(c *fiber.Ctx) error {
+	files.Delete("/:id", middleware.DenyImpersonation(), middleware.RequirePermission(model.PermFilesDelete), func(c *fiber.Ctx) error {
 		return fileUploadService.DeleteFile(c)
 	})
 }
\ No newline at end of file
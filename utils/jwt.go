@@ -3,48 +3,193 @@ package utils
 import (
 	"hello-fiber/app/model"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-var jwtSecret = []byte(getJWTSecret())
+// Nilai token_use untuk membedakan access token dengan token lain yang ditandatangani key yang sama
+const (
+	TokenUseAccess             = "access"
+	TokenUseEmailVerification  = "email_verification"
+	TokenUseTwoFactorChallenge = "2fa_challenge"
+)
 
-func getJWTSecret() string {
-	if s := os.Getenv("JWT_SECRET"); s != "" {
-		return s
-	}
-	return "your-secret-key"
+// Nilai amr (authentication methods reference, RFC 8176)
+const (
+	AMRPassword      = "pwd"
+	AMROTP           = "otp"
+	AMROIDC          = "oidc" // login lewat IdP OIDC
+	AMRImpersonation = "imp"  // token impersonasi yang diterbitkan untuk admin
+)
+
+type Claims struct {
+	UserID     string   `json:"user_id"` // Using json tags (not bson) because JWT is JSON Web Token
+	Email      string   `json:"email"`
+	RoleID     string   `json:"role_id"` // Changed from int to string to store ObjectID hex
+	TokenUse   string   `json:"token_use,omitempty"`
+	Unverified bool     `json:"unverified,omitempty"` // true jika email user belum diverifikasi
+	AMR        []string `json:"amr,omitempty"`
+	SessionID  string   `json:"sid,omitempty"` // ID session (family refresh token) tempat token ini diterbitkan
+	Actor      *Actor   `json:"act,omitempty"` // admin yang sedang melakukan impersonasi (RFC 8693)
+	jwt.RegisteredClaims
 }
 
-func GetJWTSecret() []byte {
-	return jwtSecret
+// Actor adalah pihak yang sebenarnya memakai token impersonasi
+type Actor struct {
+	Subject string `json:"sub"`
+	Email   string `json:"email,omitempty"`
 }
 
-type Claims struct {
-	UserID string `json:"user_id"` // Using json tags (not bson) because JWT is JSON Web Token
-	Email  string `json:"email"`
-	RoleID string `json:"role_id"` // Changed from int to string to store ObjectID hex
-	jwt.RegisteredClaims
+// IsImpersonation bernilai true jika token dipakai admin atas nama user lain
+func (c *Claims) IsImpersonation() bool {
+	return c.Actor != nil && c.Actor.Subject != ""
+}
+
+// IsAccessToken bernilai true untuk access token (token lama tanpa token_use juga dianggap access token)
+func (c *Claims) IsAccessToken() bool {
+	return c.TokenUse == "" || c.TokenUse == TokenUseAccess
+}
+
+// HasAMR mengecek apakah token diterbitkan dengan metode autentikasi tertentu
+func (c *Claims) HasAMR(method string) bool {
+	for _, m := range c.AMR {
+		if m == method {
+			return true
+		}
+	}
+	return false
 }
 
 // GenerateJWT generates a JWT token for authenticated user
-func GenerateJWT(user model.User) (string, error) {
-	uidStr := user.ID.Hex() // Convert ObjectID to hex string
+func GenerateJWT(user model.User, sessionID string, amr ...string) (string, error) {
+	uidStr := user.ID.Hex()        // Convert ObjectID to hex string
 	roleIDStr := user.RoleID.Hex() // Convert RoleID ObjectID to hex string
 	claims := Claims{
-		UserID: uidStr,
-		Email:  user.Email,
-		RoleID: roleIDStr,
+		UserID:     uidStr,
+		Email:      user.Email,
+		RoleID:     roleIDStr,
+		TokenUse:   TokenUseAccess,
+		Unverified: !user.EmailVerified,
+		AMR:        amr,
+		SessionID:  sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(GetAccessTokenTTL())),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   uidStr,
+			ID:        uuid.New().String(), // jti, dipakai untuk revocation
+		},
+	}
+
+	return SignToken(claims)
+}
+
+// GenerateImpersonationJWT membuat access token berumur pendek atas nama user dengan claim act berisi admin.
+// Token ini tidak punya refresh token maupun session (masa berlaku dari IMPERSONATION_TTL, default 15 menit).
+func GenerateImpersonationJWT(user model.User, actor model.User, amr ...string) (string, time.Time, error) {
+	uidStr := user.ID.Hex()
+	expiresAt := time.Now().Add(GetImpersonationTTL())
+	claims := Claims{
+		UserID:     uidStr,
+		Email:      user.Email,
+		RoleID:     user.RoleID.Hex(),
+		TokenUse:   TokenUseAccess,
+		Unverified: !user.EmailVerified,
+		AMR:        append([]string{AMRImpersonation}, amr...),
+		Actor:      &Actor{Subject: actor.ID.Hex(), Email: actor.Email},
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   uidStr,
+			ID:        uuid.New().String(),
+		},
+	}
+
+	token, err := SignToken(claims)
+	return token, expiresAt, err
+}
+
+// GenerateEmailVerificationToken membuat token bertanda tangan untuk link verifikasi email.
+// Email ikut disimpan sehingga link otomatis tidak berlaku jika email user diganti.
+func GenerateEmailVerificationToken(user model.User) (string, error) {
+	uidStr := user.ID.Hex()
+	claims := Claims{
+		UserID:   uidStr,
+		Email:    user.Email,
+		TokenUse: TokenUseEmailVerification,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(GetEnvDuration("EMAIL_VERIFICATION_TTL", 24*time.Hour))),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   uidStr,
+		},
+	}
+	return SignToken(claims)
+}
+
+// ParseEmailVerificationToken memverifikasi token dari link verifikasi email
+func ParseEmailVerificationToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := ParseToken(tokenString, claims)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid || claims.TokenUse != TokenUseEmailVerification {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	return claims, nil
+}
+
+// GenerateTwoFactorChallengeToken membuat token berumur pendek untuk langkah kedua login 2FA
+func GenerateTwoFactorChallengeToken(user model.User) (string, error) {
+	uidStr := user.ID.Hex()
+	claims := Claims{
+		UserID:   uidStr,
+		Email:    user.Email,
+		TokenUse: TokenUseTwoFactorChallenge,
+		AMR:      []string{AMRPassword},
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(GetEnvDuration("TWO_FACTOR_CHALLENGE_TTL", 5*time.Minute))),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Subject:   uidStr,
 		},
 	}
+	return SignToken(claims)
+}
+
+// ParseTwoFactorChallengeToken memverifikasi challenge token dari langkah pertama login
+func ParseTwoFactorChallengeToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := ParseToken(tokenString, claims)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid || claims.TokenUse != TokenUseTwoFactorChallenge {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	return claims, nil
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+// GetAccessTokenTTL membaca masa berlaku access token dari JWT_ACCESS_TTL (default 15 menit)
+func GetAccessTokenTTL() time.Duration {
+	return GetEnvDuration("JWT_ACCESS_TTL", 15*time.Minute)
+}
+
+// GetImpersonationTTL membaca masa berlaku token impersonation dari IMPERSONATION_TTL (default 15 menit)
+func GetImpersonationTTL() time.Duration {
+	return GetEnvDuration("IMPERSONATION_TTL", 15*time.Minute)
+}
+
+// GetMaxAccessTokenTTL mengembalikan masa berlaku terpanjang token akses (biasa atau impersonation),
+// dipakai sebagai masa simpan catatan pencabutan semua token user
+func GetMaxAccessTokenTTL() time.Duration {
+	return max(GetAccessTokenTTL(), GetImpersonationTTL())
+}
+
+// GetRefreshTokenTTL membaca masa berlaku refresh token dari JWT_REFRESH_TTL (default 30 hari)
+func GetRefreshTokenTTL() time.Duration {
+	return GetEnvDuration("JWT_REFRESH_TTL", 30*24*time.Hour)
 }
 
 func GetEnv(key, defaultValue string) string {
@@ -53,3 +198,23 @@ func GetEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// GetEnvInt membaca bilangan bulat positif dari environment
+func GetEnvInt(key string, defaultValue int) int {
+	if value, exists := os.LookupEnv(key); exists {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+// GetEnvDuration membaca durasi (format time.ParseDuration, mis. "15m") dari environment
+func GetEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, exists := os.LookupEnv(key); exists {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			return d
+		}
+	}
+	return defaultValue
+}
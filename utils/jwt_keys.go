@@ -0,0 +1,249 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Konfigurasi penandatanganan JWT:
+//
+//	JWT_ALG         HS256 (default), RS256, atau EdDSA
+//	JWT_SECRET      secret HS256, minimal 32 byte (wajib jika JWT_ALG=HS256)
+//	JWT_KEYS_DIR    folder berisi key PEM, nama file (tanpa .pem) dipakai sebagai kid
+//	JWT_ACTIVE_KID  kid yang dipakai untuk menandatangani token baru
+//	JWT_ACCEPT_HS256 "true" agar token HS256 lama tetap diterima setelah migrasi ke RS256/EdDSA
+//
+// File private key (PKCS#1/PKCS#8) dipakai untuk sign dan verify, file public key
+// (PKIX) hanya untuk verify. Prosedur rotasi:
+//  1. Buat key baru, mis. `openssl genpkey -algorithm ed25519 -out keys/2025-02.pem`
+//  2. Restart dengan JWT_ACTIVE_KID=2025-02. Key lama tetap dimuat sehingga token
+//     lama masih valid dan tetap dipublikasikan di /.well-known/jwks.json
+//  3. Setelah JWT_ACCESS_TTL terlewati, hapus file key lama (atau ganti dengan
+//     public key-nya saja jika masih ingin dipublikasikan).
+
+type jwtKey struct {
+	kid       string
+	method    jwt.SigningMethod
+	signKey   crypto.PrivateKey // nil jika key hanya untuk verifikasi
+	verifyKey crypto.PublicKey
+}
+
+type jwtKeySet struct {
+	active     *jwtKey
+	keys       map[string]*jwtKey
+	hmacSecret []byte
+}
+
+var (
+	keySetMu sync.Mutex
+	keySet   *jwtKeySet
+)
+
+// LoadJWTKeys memuat (ulang) key JWT dari environment dan JWT_KEYS_DIR
+func LoadJWTKeys() error {
+	ks, err := loadJWTKeySet()
+	if err != nil {
+		return err
+	}
+	keySetMu.Lock()
+	keySet = ks
+	keySetMu.Unlock()
+	return nil
+}
+
+func currentKeySet() (*jwtKeySet, error) {
+	keySetMu.Lock()
+	defer keySetMu.Unlock()
+	if keySet == nil {
+		ks, err := loadJWTKeySet()
+		if err != nil {
+			return nil, err
+		}
+		keySet = ks
+	}
+	return keySet, nil
+}
+
+// minHMACSecretLength adalah panjang minimum JWT_SECRET (256 bit, sesuai ukuran output SHA-256)
+const minHMACSecretLength = 32
+
+func loadJWTKeySet() (*jwtKeySet, error) {
+	alg := strings.ToUpper(GetEnv("JWT_ALG", "HS256"))
+	secret := os.Getenv("JWT_SECRET")
+	ks := &jwtKeySet{keys: map[string]*jwtKey{}}
+
+	if alg == "HS256" {
+		if secret == "" {
+			return nil, errors.New("JWT_SECRET wajib diisi untuk JWT_ALG=HS256")
+		}
+		if len(secret) < minHMACSecretLength {
+			return nil, fmt.Errorf("JWT_SECRET minimal %d byte, buat dengan mis. `openssl rand -base64 48`", minHMACSecretLength)
+		}
+		ks.hmacSecret = []byte(secret)
+		ks.active = &jwtKey{method: jwt.SigningMethodHS256, signKey: ks.hmacSecret}
+		return ks, nil
+	}
+
+	if alg != "RS256" && alg != "EDDSA" {
+		return nil, fmt.Errorf("JWT_ALG tidak didukung: %s", alg)
+	}
+	if secret != "" && GetEnv("JWT_ACCEPT_HS256", "false") == "true" {
+		if len(secret) < minHMACSecretLength {
+			return nil, fmt.Errorf("JWT_SECRET minimal %d byte untuk JWT_ACCEPT_HS256", minHMACSecretLength)
+		}
+		ks.hmacSecret = []byte(secret)
+	}
+
+	dir := GetEnv("JWT_KEYS_DIR", "./keys")
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		key, err := loadJWTKeyFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("gagal memuat key %s: %w", file, err)
+		}
+		ks.keys[key.kid] = key
+	}
+
+	activeKID := os.Getenv("JWT_ACTIVE_KID")
+	active, ok := ks.keys[activeKID]
+	if !ok {
+		return nil, fmt.Errorf("JWT_ACTIVE_KID %q tidak ditemukan di %s", activeKID, dir)
+	}
+	if active.signKey == nil {
+		return nil, fmt.Errorf("key aktif %q harus berupa private key", activeKID)
+	}
+	if strings.ToUpper(active.method.Alg()) != alg {
+		return nil, fmt.Errorf("key aktif %q bertipe %s, bukan %s", activeKID, active.method.Alg(), alg)
+	}
+	ks.active = active
+	return ks, nil
+}
+
+func loadJWTKeyFile(path string) (*jwtKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("bukan file PEM")
+	}
+
+	key := &jwtKey{kid: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))}
+
+	var parsed interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("tipe PEM tidak didukung: %s", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.method, key.signKey, key.verifyKey = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.method, key.verifyKey = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.method, key.signKey, key.verifyKey = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.method, key.verifyKey = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, errors.New("hanya key RSA dan Ed25519 yang didukung")
+	}
+	return key, nil
+}
+
+// SignToken menandatangani claims dengan key aktif (header kid diisi untuk key asimetris)
+func SignToken(claims jwt.Claims) (string, error) {
+	ks, err := currentKeySet()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(ks.active.method, claims)
+	if ks.active.kid != "" {
+		token.Header["kid"] = ks.active.kid
+	}
+	return token.SignedString(ks.active.signKey)
+}
+
+// ParseToken memverifikasi token dengan key sesuai header kid.
+// Token tanpa kid hanya diterima jika HS256 aktif/diizinkan.
+func ParseToken(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	ks, err := currentKeySet()
+	if err != nil {
+		return nil, err
+	}
+	return jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			if ks.hmacSecret != nil && token.Method == jwt.SigningMethodHS256 {
+				return ks.hmacSecret, nil
+			}
+			return nil, jwt.ErrTokenUnverifiable
+		}
+		key, ok := ks.keys[kid]
+		if !ok || token.Method.Alg() != key.method.Alg() {
+			return nil, jwt.ErrTokenUnverifiable
+		}
+		return key.verifyKey, nil
+	}, jwt.WithValidMethods([]string{"HS256", "RS256", "EdDSA"}))
+}
+
+// JWKS mengembalikan public key (RS256/EdDSA) dalam format JSON Web Key Set
+func JWKS() map[string]interface{} {
+	keys := []map[string]string{}
+	ks, err := currentKeySet()
+	if err != nil {
+		return map[string]interface{}{"keys": keys}
+	}
+
+	kids := make([]string, 0, len(ks.keys))
+	for kid := range ks.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	for _, kid := range kids {
+		key := ks.keys[kid]
+		jwk := map[string]string{"kid": kid, "use": "sig", "alg": key.method.Alg()}
+		switch pub := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwk["kty"] = "RSA"
+			jwk["n"] = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk["e"] = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk["kty"] = "OKP"
+			jwk["crv"] = "Ed25519"
+			jwk["x"] = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		keys = append(keys, jwk)
+	}
+	return map[string]interface{}{"keys": keys}
+}
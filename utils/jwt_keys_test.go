@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadJWTKeySetHMACSecretLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr bool
+	}{
+		{name: "kosong", secret: "", wantErr: true},
+		{name: "placeholder .env", secret: "change_me", wantErr: true},
+		{name: "31 byte", secret: strings.Repeat("a", 31), wantErr: true},
+		{name: "32 byte", secret: strings.Repeat("a", 32), wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("JWT_ALG", "HS256")
+			t.Setenv("JWT_SECRET", tt.secret)
+			_, err := loadJWTKeySet()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadJWTKeySet error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
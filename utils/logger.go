@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"context"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Konfigurasi logger:
+//
+//	LOG_FORMAT  json (default) atau text
+//	LOG_LEVEL   debug, info (default), warn, atau error
+//
+// Semua log melewati redaksi: atribut dengan nama sensitif (password, token, secret, dll.)
+// diganti [REDACTED], dan nilai yang terlihat seperti JWT, bearer token, atau api key disamarkan.
+// Log yang ditulis dengan context berisi span (mis. slog.InfoContext(c.UserContext(), ...))
+// otomatis mendapat trace_id dan span_id.
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys adalah potongan nama atribut yang nilainya tidak boleh masuk log
+var sensitiveKeys = []string{
+	"password", "passwd", "secret", "token", "authorization", "cookie",
+	"api_key", "apikey", "code_verifier", "recovery_code", "pepper", "otp",
+}
+
+var sensitiveValuePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9._~+/=-]+`),
+	regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`), // JWT
+	regexp.MustCompile(`\bak_[A-Za-z0-9_-]{16,}`),                           // api key
+}
+
+// IsSensitiveKey mengecek apakah nama field/atribut berisi data rahasia
+func IsSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	if key == "code" {
+		return true
+	}
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactString menyamarkan token yang terselip di dalam teks bebas (mis. pesan error)
+func RedactString(s string) string {
+	for _, re := range sensitiveValuePatterns {
+		s = re.ReplaceAllString(s, redacted)
+	}
+	return s
+}
+
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if a.Key != slog.MessageKey && IsSensitiveKey(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(RedactString(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			a.Value = slog.StringValue(RedactString(err.Error()))
+		}
+	}
+	return a
+}
+
+// NewLogger membuat logger slog ke w sesuai LOG_FORMAT dan LOG_LEVEL
+func NewLogger(w io.Writer) *slog.Logger {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(GetEnv("LOG_LEVEL", "info"))); err != nil {
+		level = slog.LevelInfo
+	}
+
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: redactAttr}
+	var handler slog.Handler
+	if strings.ToLower(GetEnv("LOG_FORMAT", "json")) == "text" {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(traceHandler{handler})
+}
+
+// traceHandler menambahkan trace_id dan span_id dari span aktif di context ke setiap log
+type traceHandler struct {
+	slog.Handler
+}
+
+func (h traceHandler) Handle(ctx context.Context, r slog.Record) error {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return traceHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h traceHandler) WithGroup(name string) slog.Handler {
+	return traceHandler{h.Handler.WithGroup(name)}
+}
+
+// InitLogger memasang logger aplikasi sebagai slog default. Output package log standar
+// (termasuk dari library) ikut diteruskan ke logger ini.
+func InitLogger() *slog.Logger {
+	logger := NewLogger(os.Stdout)
+	slog.SetDefault(logger)
+	log.SetFlags(0)
+	return logger
+}
+
+// Fatal mencatat error lalu menghentikan program
+func Fatal(msg string, args ...any) {
+	slog.Default().Log(context.Background(), slog.LevelError, msg, args...)
+	os.Exit(1)
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"fmt"
+	"log/slog"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mailer mengirim email teks sederhana. Implementasi dipilih lewat MAIL_DRIVER.
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// SMTPMailer mengirim email lewat server SMTP (STARTTLS dipakai otomatis jika didukung server)
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	msg := strings.Join([]string{
+		"From: " + m.From,
+		"To: " + to,
+		"Subject: " + subject,
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	return smtp.SendMail(m.Host+":"+m.Port, auth, m.From, []string{to}, []byte(msg))
+}
+
+// LogMailer untuk development: email ditulis ke file (MAIL_LOG_FILE). Jika kosong hanya penerima dan subjek yang dicatat di log.
+type LogMailer struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func (m *LogMailer) Send(to, subject, body string) error {
+	if m.Path == "" {
+		// Isi email berisi link dengan token rahasia sehingga tidak ditulis ke log.
+		// Set MAIL_LOG_FILE untuk membaca isi email saat development.
+		slog.Info("Email tidak dikirim (MAIL_DRIVER=log)", "to", to, "subject", subject)
+		return nil
+	}
+	entry := fmt.Sprintf("=== %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC3339), to, subject, body)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, err := os.OpenFile(m.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(entry)
+	return err
+}
+
+var (
+	mailerOnce sync.Once
+	mailer     Mailer
+)
+
+// GetMailer mengembalikan Mailer sesuai MAIL_DRIVER ("smtp" atau "log", default "log")
+func GetMailer() Mailer {
+	mailerOnce.Do(func() {
+		if strings.ToLower(GetEnv("MAIL_DRIVER", "log")) == "smtp" {
+			mailer = &SMTPMailer{
+				Host:     os.Getenv("SMTP_HOST"),
+				Port:     GetEnv("SMTP_PORT", "587"),
+				Username: os.Getenv("SMTP_USERNAME"),
+				Password: os.Getenv("SMTP_PASSWORD"),
+				From:     GetEnv("MAIL_FROM", "no-reply@localhost"),
+			}
+			return
+		}
+		mailer = &LogMailer{Path: os.Getenv("MAIL_LOG_FILE")}
+	})
+	return mailer
+}
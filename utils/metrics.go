@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.mongodb.org/mongo-driver/v2/event"
+)
+
+// Metrik Prometheus aplikasi, diekspos di GET /metrics (lihat METRICS_TOKEN di route).
+// Label route memakai template route Fiber (mis. /api/users/:id) supaya jumlah seri tetap kecil.
+var (
+	HTTPRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Jumlah request HTTP per method, route, dan status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Latensi request HTTP per method, route, dan status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	mongoCommandDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mongodb_command_duration_seconds",
+		Help:    "Durasi command MongoDB per nama command.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"command"})
+
+	mongoCommandErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "mongodb_command_errors_total",
+		Help: "Jumlah command MongoDB yang gagal per nama command.",
+	}, []string{"command"})
+
+	mongoPoolConnections = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "mongodb_pool_connections",
+		Help: "Jumlah koneksi terbuka di connection pool MongoDB.",
+	})
+
+	mongoPoolInUse = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "mongodb_pool_connections_in_use",
+		Help: "Jumlah koneksi MongoDB yang sedang dipakai.",
+	})
+
+	mongoPoolCheckoutFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "mongodb_pool_checkout_failures_total",
+		Help: "Jumlah kegagalan mengambil koneksi dari pool MongoDB per alasan.",
+	}, []string{"reason"})
+
+	loginAttemptsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_login_attempts_total",
+		Help: "Jumlah percobaan login per hasil (success/failure).",
+	}, []string{"result"})
+
+	uploadsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "file_uploads_total",
+		Help: "Jumlah upload file per tipe dan hasil (success/rejected/error).",
+	}, []string{"type", "result"})
+
+	uploadBytesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "file_upload_bytes_total",
+		Help: "Total byte file yang berhasil diupload per tipe.",
+	}, []string{"type"})
+)
+
+// ObserveHTTPRequest mencatat satu request HTTP yang sudah selesai
+func ObserveHTTPRequest(method, route string, status int, seconds float64) {
+	code := strconv.Itoa(status)
+	HTTPRequestsTotal.WithLabelValues(method, route, code).Inc()
+	HTTPRequestDuration.WithLabelValues(method, route, code).Observe(seconds)
+}
+
+// ObserveLogin mencatat hasil percobaan login
+func ObserveLogin(success bool) {
+	result := "failure"
+	if success {
+		result = "success"
+	}
+	loginAttemptsTotal.WithLabelValues(result).Inc()
+}
+
+// ObserveUpload mencatat hasil upload file; size hanya dihitung untuk upload yang berhasil
+func ObserveUpload(fileType, result string, size int64) {
+	uploadsTotal.WithLabelValues(fileType, result).Inc()
+	if result == "success" {
+		uploadBytesTotal.WithLabelValues(fileType).Add(float64(size))
+	}
+}
+
+// MongoCommandMonitor mencatat durasi dan error setiap command MongoDB
+func MongoCommandMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			mongoCommandDuration.WithLabelValues(e.CommandName).Observe(e.Duration.Seconds())
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			mongoCommandDuration.WithLabelValues(e.CommandName).Observe(e.Duration.Seconds())
+			mongoCommandErrors.WithLabelValues(e.CommandName).Inc()
+		},
+	}
+}
+
+// MongoPoolMonitor menjaga gauge jumlah koneksi pool MongoDB
+func MongoPoolMonitor() *event.PoolMonitor {
+	return &event.PoolMonitor{
+		Event: func(e *event.PoolEvent) {
+			switch e.Type {
+			case event.ConnectionCreated:
+				mongoPoolConnections.Inc()
+			case event.ConnectionClosed:
+				mongoPoolConnections.Dec()
+			case event.ConnectionCheckedOut:
+				mongoPoolInUse.Inc()
+			case event.ConnectionCheckedIn:
+				mongoPoolInUse.Dec()
+			case event.ConnectionCheckOutFailed:
+				mongoPoolCheckoutFailures.WithLabelValues(e.Reason).Inc()
+			}
+		},
+	}
+}
@@ -0,0 +1,331 @@
+package utils
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Konfigurasi login OIDC (authorization code + PKCE):
+//
+//	OIDC_ISSUER         URL issuer IdP, discovery dibaca dari <issuer>/.well-known/openid-configuration
+//	OIDC_CLIENT_ID      client ID aplikasi di IdP
+//	OIDC_CLIENT_SECRET  opsional, kosongkan untuk public client (PKCE saja)
+//	OIDC_REDIRECT_URL   URL callback, mis. http://localhost:3000/api/auth/oidc/callback
+//	OIDC_SCOPES         default "openid email profile"
+//
+// Issuer boleh http:// sehingga bisa diuji dengan mock IdP lokal.
+
+var ErrOIDCNotConfigured = errors.New("OIDC belum dikonfigurasi")
+
+// OIDCConfig berisi konfigurasi client OIDC dari environment
+type OIDCConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       string
+}
+
+// OIDCIDTokenClaims adalah claim ID token yang dipakai aplikasi
+type OIDCIDTokenClaims struct {
+	Email             string   `json:"email"`
+	EmailVerified     *bool    `json:"email_verified"`
+	PreferredUsername string   `json:"preferred_username"`
+	Name              string   `json:"name"`
+	Nonce             string   `json:"nonce"`
+	AMR               []string `json:"amr"`
+	jwt.RegisteredClaims
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type oidcProvider struct {
+	discovery oidcDiscovery
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+var (
+	oidcMu         sync.Mutex
+	oidcCache      *oidcProvider
+	oidcHTTPClient = &http.Client{Timeout: 10 * time.Second, Transport: NewTracingTransport(nil)}
+)
+
+// GetOIDCConfig membaca konfigurasi OIDC, mengembalikan ErrOIDCNotConfigured jika belum lengkap
+func GetOIDCConfig() (*OIDCConfig, error) {
+	cfg := &OIDCConfig{
+		Issuer:       strings.TrimSuffix(GetEnv("OIDC_ISSUER", ""), "/"),
+		ClientID:     GetEnv("OIDC_CLIENT_ID", ""),
+		ClientSecret: GetEnv("OIDC_CLIENT_SECRET", ""),
+		RedirectURL:  GetEnv("OIDC_REDIRECT_URL", ""),
+		Scopes:       GetEnv("OIDC_SCOPES", "openid email profile"),
+	}
+	if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, ErrOIDCNotConfigured
+	}
+	return cfg, nil
+}
+
+// PKCEChallenge menghitung code_challenge metode S256 dari code_verifier
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// OIDCAuthURL membuat URL authorization endpoint IdP
+func OIDCAuthURL(ctx context.Context, cfg *OIDCConfig, state, nonce, codeVerifier string) (string, error) {
+	p, err := getOIDCProvider(ctx, cfg, false)
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", cfg.ClientID)
+	q.Set("redirect_uri", cfg.RedirectURL)
+	q.Set("scope", cfg.Scopes)
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", PKCEChallenge(codeVerifier))
+	q.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(p.discovery.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return p.discovery.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// OIDCExchangeCode menukar authorization code dengan token di IdP lalu memverifikasi ID token-nya
+func OIDCExchangeCode(ctx context.Context, cfg *OIDCConfig, code, codeVerifier, nonce string) (*OIDCIDTokenClaims, error) {
+	p, err := getOIDCProvider(ctx, cfg, false)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", cfg.RedirectURL)
+	form.Set("client_id", cfg.ClientID)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(cfg.ClientID), url.QueryEscape(cfg.ClientSecret))
+	}
+
+	resp, err := oidcHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("respons token endpoint tidak valid: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint menolak code: %s %s", body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return nil, errors.New("token endpoint tidak mengembalikan id_token")
+	}
+
+	return verifyOIDCIDToken(ctx, cfg, body.IDToken, nonce)
+}
+
+// verifyOIDCIDToken memverifikasi tanda tangan, issuer, audience, masa berlaku, dan nonce ID token
+func verifyOIDCIDToken(ctx context.Context, cfg *OIDCConfig, rawIDToken, nonce string) (*OIDCIDTokenClaims, error) {
+	claims := &OIDCIDTokenClaims{}
+	keyFunc := func(refresh bool) jwt.Keyfunc {
+		return func(token *jwt.Token) (interface{}, error) {
+			p, err := getOIDCProvider(ctx, cfg, refresh)
+			if err != nil {
+				return nil, err
+			}
+			kid, _ := token.Header["kid"].(string)
+			if key, ok := p.keys[kid]; ok {
+				return key, nil
+			}
+			// IdP dengan satu key kadang tidak mengirim kid
+			if kid == "" && len(p.keys) == 1 {
+				for _, key := range p.keys {
+					return key, nil
+				}
+			}
+			return nil, jwt.ErrTokenUnverifiable
+		}
+	}
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "EdDSA"}),
+		jwt.WithIssuer(cfg.Issuer),
+		jwt.WithAudience(cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	}
+
+	_, err := jwt.ParseWithClaims(rawIDToken, claims, keyFunc(false), opts...)
+	if err != nil && errors.Is(err, jwt.ErrTokenUnverifiable) {
+		// kid tidak dikenal, kemungkinan IdP baru merotasi key: ambil ulang JWKS sekali
+		claims = &OIDCIDTokenClaims{}
+		_, err = jwt.ParseWithClaims(rawIDToken, claims, keyFunc(true), opts...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Subject == "" {
+		return nil, errors.New("id_token tidak memiliki sub")
+	}
+	if claims.Nonce != nonce {
+		return nil, errors.New("nonce id_token tidak cocok")
+	}
+	return claims, nil
+}
+
+// getOIDCProvider mengambil discovery document dan JWKS IdP (di-cache selama satu jam)
+func getOIDCProvider(ctx context.Context, cfg *OIDCConfig, forceRefresh bool) (*oidcProvider, error) {
+	oidcMu.Lock()
+	defer oidcMu.Unlock()
+
+	if !forceRefresh && oidcCache != nil && oidcCache.discovery.Issuer == cfg.Issuer && time.Since(oidcCache.fetchedAt) < time.Hour {
+		return oidcCache, nil
+	}
+
+	var d oidcDiscovery
+	if err := oidcGetJSON(ctx, cfg.Issuer+"/.well-known/openid-configuration", &d); err != nil {
+		return nil, fmt.Errorf("gagal membaca discovery OIDC: %w", err)
+	}
+	if strings.TrimSuffix(d.Issuer, "/") != cfg.Issuer {
+		return nil, fmt.Errorf("issuer discovery (%s) tidak sama dengan OIDC_ISSUER", d.Issuer)
+	}
+	d.Issuer = cfg.Issuer
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, errors.New("discovery OIDC tidak lengkap")
+	}
+
+	var jwks struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	if err := oidcGetJSON(ctx, d.JWKSURI, &jwks); err != nil {
+		return nil, fmt.Errorf("gagal membaca JWKS OIDC: %w", err)
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, jwk := range jwks.Keys {
+		if use := jwk["use"]; use != "" && use != "sig" {
+			continue
+		}
+		key, err := parseJWK(jwk)
+		if err != nil {
+			continue
+		}
+		keys[jwk["kid"]] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS OIDC tidak berisi key yang didukung")
+	}
+
+	oidcCache = &oidcProvider{discovery: d, keys: keys, fetchedAt: time.Now()}
+	return oidcCache, nil
+}
+
+func oidcGetJSON(ctx context.Context, u string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := oidcHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s mengembalikan status %d", u, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// parseJWK mengubah JWK (RSA, EC P-256/P-384, atau Ed25519) menjadi public key
+func parseJWK(jwk map[string]string) (crypto.PublicKey, error) {
+	decode := func(field string) ([]byte, error) {
+		return base64.RawURLEncoding.DecodeString(strings.TrimRight(jwk[field], "="))
+	}
+
+	switch jwk["kty"] {
+	case "RSA":
+		n, err := decode("n")
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode("e")
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk["crv"] {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, errors.New("kurva EC tidak didukung")
+		}
+		x, err := decode("x")
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode("y")
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		if jwk["crv"] != "Ed25519" {
+			return nil, errors.New("kurva OKP tidak didukung")
+		}
+		x, err := decode("x")
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("ukuran key Ed25519 tidak valid")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, errors.New("kty tidak didukung")
+}
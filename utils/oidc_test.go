@@ -0,0 +1,136 @@
+package utils_test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"hello-fiber/utils"
+	"hello-fiber/utils/oidctest"
+)
+
+func newMockIdP(t *testing.T) (*oidctest.Provider, *utils.OIDCConfig) {
+	t.Helper()
+	idp, err := oidctest.New("alumni-api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(idp.Close)
+	return idp, &utils.OIDCConfig{
+		Issuer:      idp.Issuer(),
+		ClientID:    "alumni-api",
+		RedirectURL: "http://localhost:3000/api/auth/oidc/callback",
+		Scopes:      "openid email profile",
+	}
+}
+
+func TestOIDCAuthURL(t *testing.T) {
+	idp, cfg := newMockIdP(t)
+
+	authURL, err := utils.OIDCAuthURL(context.Background(), cfg, "state-1", "nonce-1", "verifier-1")
+	if err != nil {
+		t.Fatalf("OIDCAuthURL: %v", err)
+	}
+	if !strings.HasPrefix(authURL, idp.Issuer()+"/authorize?") {
+		t.Fatalf("authorization endpoint dari discovery tidak dipakai: %s", authURL)
+	}
+
+	u, _ := url.Parse(authURL)
+	q := u.Query()
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             cfg.ClientID,
+		"redirect_uri":          cfg.RedirectURL,
+		"scope":                 cfg.Scopes,
+		"state":                 "state-1",
+		"nonce":                 "nonce-1",
+		"code_challenge":        utils.PKCEChallenge("verifier-1"),
+		"code_challenge_method": "S256",
+	}
+	for k, v := range want {
+		if got := q.Get(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestOIDCAuthURLRejectsIssuerMismatch(t *testing.T) {
+	_, cfg := newMockIdP(t)
+	cfg.Issuer = strings.Replace(cfg.Issuer, "127.0.0.1", "localhost", 1)
+
+	if _, err := utils.OIDCAuthURL(context.Background(), cfg, "s", "n", "v"); err == nil {
+		t.Fatal("discovery dengan issuer berbeda harus ditolak")
+	}
+}
+
+func TestOIDCExchangeCode(t *testing.T) {
+	const nonce, verifier = "nonce-123", "verifier-123"
+
+	tests := []struct {
+		name         string
+		exchangeCode func(code string) string
+		verifier     string
+		nonce        string
+		wantErr      bool
+	}{
+		{name: "valid", verifier: verifier, nonce: nonce},
+		{name: "PKCE verifier salah", verifier: "verifier-lain", nonce: nonce, wantErr: true},
+		{name: "nonce tidak cocok", verifier: verifier, nonce: "nonce-lain", wantErr: true},
+		{name: "code tidak dikenal", exchangeCode: func(string) string { return "code-palsu" }, verifier: verifier, nonce: nonce, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp, cfg := newMockIdP(t)
+			authURL, err := utils.OIDCAuthURL(context.Background(), cfg, "state", nonce, verifier)
+			if err != nil {
+				t.Fatal(err)
+			}
+			code, _, err := idp.Authorize(authURL, map[string]interface{}{
+				"sub":            "idp-user-1",
+				"email":          "user@example.com",
+				"email_verified": true,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.exchangeCode != nil {
+				code = tt.exchangeCode(code)
+			}
+
+			claims, err := utils.OIDCExchangeCode(context.Background(), cfg, code, tt.verifier, tt.nonce)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("exchange seharusnya gagal")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("OIDCExchangeCode: %v", err)
+			}
+			if claims.Subject != "idp-user-1" || claims.Email != "user@example.com" || claims.EmailVerified == nil || !*claims.EmailVerified {
+				t.Fatalf("claims tidak sesuai: %+v", claims)
+			}
+		})
+	}
+}
+
+func TestOIDCExchangeCodeIsSingleUse(t *testing.T) {
+	idp, cfg := newMockIdP(t)
+	authURL, err := utils.OIDCAuthURL(context.Background(), cfg, "state", "nonce", "verifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _, err := idp.Authorize(authURL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := utils.OIDCExchangeCode(context.Background(), cfg, code, "verifier", "nonce"); err != nil {
+		t.Fatalf("exchange pertama: %v", err)
+	}
+	if _, err := utils.OIDCExchangeCode(context.Background(), cfg, code, "verifier", "nonce"); err == nil {
+		t.Fatal("code yang sama tidak boleh bisa ditukar dua kali")
+	}
+}
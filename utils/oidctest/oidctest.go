@@ -0,0 +1,165 @@
+// Package oidctest menyediakan IdP OIDC tiruan berbasis httptest untuk menguji login OIDC
+// (discovery, JWKS, dan token endpoint dengan pemeriksaan PKCE S256) tanpa IdP sungguhan.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const keyID = "oidctest-key"
+
+type authRequest struct {
+	clientID      string
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+	claims        map[string]interface{}
+}
+
+// Provider adalah IdP tiruan. Code authorization hanya bisa ditukar sekali.
+type Provider struct {
+	Server   *httptest.Server
+	ClientID string
+
+	key   *rsa.PrivateKey
+	mu    sync.Mutex
+	codes map[string]authRequest
+}
+
+// New menjalankan IdP tiruan untuk clientID. Panggil Close setelah selesai.
+func New(clientID string) (*Provider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	p := &Provider{ClientID: clientID, key: key, codes: map[string]authRequest{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/jwks", p.jwks)
+	mux.HandleFunc("/token", p.token)
+	p.Server = httptest.NewServer(mux)
+	return p, nil
+}
+
+// Issuer mengembalikan URL issuer IdP (dipakai sebagai OIDC_ISSUER)
+func (p *Provider) Issuer() string {
+	return p.Server.URL
+}
+
+// Close mematikan server IdP
+func (p *Provider) Close() {
+	p.Server.Close()
+}
+
+// Authorize mensimulasikan user yang berhasil login di IdP: membaca parameter authorization URL
+// lalu mengembalikan code dan state yang akan dikirim IdP ke redirect URL. claims ditambahkan ke
+// ID token (mis. sub, email, email_verified).
+func (p *Provider) Authorize(authURL string, claims map[string]interface{}) (code, state string, err error) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	q := u.Query()
+	if q.Get("response_type") != "code" || q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		return "", "", errors.New("authorization request tidak memakai code + PKCE S256")
+	}
+
+	code = rand.Text()
+	p.mu.Lock()
+	p.codes[code] = authRequest{
+		clientID:      q.Get("client_id"),
+		redirectURI:   q.Get("redirect_uri"),
+		nonce:         q.Get("nonce"),
+		codeChallenge: q.Get("code_challenge"),
+		claims:        claims,
+	}
+	p.mu.Unlock()
+	return code, q.Get("state"), nil
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 p.Issuer(),
+		"authorization_endpoint": p.Issuer() + "/authorize",
+		"token_endpoint":         p.Issuer() + "/token",
+		"jwks_uri":               p.Issuer() + "/jwks",
+	})
+}
+
+func (p *Provider) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := p.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": keyID,
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	p.mu.Lock()
+	req, ok := p.codes[code]
+	delete(p.codes, code)
+	p.mu.Unlock()
+	if !ok || req.clientID != r.PostForm.Get("client_id") || req.redirectURI != r.PostForm.Get("redirect_uri") {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "code tidak valid"})
+		return
+	}
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != req.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verifier tidak cocok"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   p.Issuer(),
+		"aud":   p.ClientID,
+		"sub":   "oidctest-user",
+		"nonce": req.nonce,
+		"iat":   now.Unix(),
+		"exp":   now.Add(5 * time.Minute).Unix(),
+	}
+	for k, v := range req.claims {
+		claims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyID
+	idToken, err := token.SignedString(p.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"id_token": idToken, "token_type": "Bearer"})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -1,15 +1,384 @@
 package utils
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func CheckPassword(password, hash string) bool {
-    err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-    return err == nil
+// Konfigurasi hashing password:
+//
+//	PASSWORD_HASH_ALG      argon2id (default) atau bcrypt, dipakai untuk hash baru
+//	ARGON2_MEMORY          memori dalam KiB (default 65536 = 64 MiB)
+//	ARGON2_ITERATIONS      default 3
+//	ARGON2_PARALLELISM     default 2
+//	ARGON2_SALT_LENGTH     default 16 byte
+//	ARGON2_KEY_LENGTH      default 32 byte
+//	BCRYPT_COST            default bcrypt.DefaultCost
+//	PASSWORD_PEPPER        opsional, secret server yang di-HMAC-kan ke password sebelum Argon2id
+//	PASSWORD_PEPPER_ID     ID pepper aktif (default "1"), dicatat di hash sebagai parameter k
+//	PASSWORD_OLD_PEPPERS   pepper lama untuk verifikasi saja, format "id:secret,id:secret"
+//
+// Konfigurasi divalidasi sekali oleh LoadPasswordHashers saat startup; ubah env lalu restart.
+//
+// Hash Argon2id disimpan dalam format PHC: $argon2id$v=19$m=65536,t=3,p=2[,k=<pepper id>]$<salt>$<hash>.
+// Hash bcrypt lama tetap bisa diverifikasi dan di-upgrade saat login berhasil (lihat PasswordNeedsRehash).
+
+// PasswordHasher adalah satu algoritma hashing password
+type PasswordHasher interface {
+	// Hash membuat hash baru dengan parameter saat ini
+	Hash(password string) (string, error)
+	// Verify mencocokkan password dengan hash yang didukung hasher ini
+	Verify(password, encoded string) (bool, error)
+	// NeedsRehash bernilai true jika hash dibuat dengan parameter yang sudah usang
+	NeedsRehash(encoded string) bool
+	// Supports mengecek apakah format hash dikenali hasher ini
+	Supports(encoded string) bool
+}
+
+// Argon2idHasher menghasilkan hash Argon2id dalam format PHC
+type Argon2idHasher struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+	PepperID    string
+	Pepper      []byte
+	OldPeppers  map[string][]byte
+}
+
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	pepperID    string
+	salt        []byte
+	key         []byte
+}
+
+func (h *Argon2idHasher) Supports(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	pepperID := ""
+	if len(h.Pepper) > 0 {
+		pepperID = h.PepperID
+	}
+	key := argon2.IDKey(h.input(password, h.Pepper), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+
+	params := fmt.Sprintf("m=%d,t=%d,p=%d", h.Memory, h.Iterations, h.Parallelism)
+	if pepperID != "" {
+		params += ",k=" + pepperID
+	}
+	return fmt.Sprintf("$argon2id$v=%d$%s$%s$%s", argon2.Version, params,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h *Argon2idHasher) Verify(password, encoded string) (bool, error) {
+	p, err := parseArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	var pepper []byte
+	if p.pepperID != "" {
+		switch {
+		case p.pepperID == h.PepperID && len(h.Pepper) > 0:
+			pepper = h.Pepper
+		case h.OldPeppers[p.pepperID] != nil:
+			pepper = h.OldPeppers[p.pepperID]
+		default:
+			return false, errors.New("pepper untuk hash password tidak dikonfigurasi")
+		}
+	}
+
+	key := argon2.IDKey(h.input(password, pepper), p.salt, p.iterations, p.memory, p.parallelism, uint32(len(p.key)))
+	return subtle.ConstantTimeCompare(key, p.key) == 1, nil
+}
+
+func (h *Argon2idHasher) NeedsRehash(encoded string) bool {
+	p, err := parseArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	currentPepperID := ""
+	if len(h.Pepper) > 0 {
+		currentPepperID = h.PepperID
+	}
+	return p.memory != h.Memory || p.iterations != h.Iterations || p.parallelism != h.Parallelism ||
+		uint32(len(p.salt)) != h.SaltLength || uint32(len(p.key)) != h.KeyLength || p.pepperID != currentPepperID
+}
+
+// input menggabungkan pepper ke password lewat HMAC-SHA256 (tanpa pepper password dipakai apa adanya)
+func (h *Argon2idHasher) input(password string, pepper []byte) []byte {
+	if len(pepper) == 0 {
+		return []byte(password)
+	}
+	mac := hmac.New(sha256.New, pepper)
+	mac.Write([]byte(password))
+	return mac.Sum(nil)
+}
+
+func parseArgon2id(encoded string) (*argon2Params, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, errors.New("format hash argon2id tidak valid")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, errors.New("versi argon2 tidak didukung")
+	}
+
+	p := &argon2Params{}
+	for _, kv := range strings.Split(parts[3], ",") {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, errors.New("parameter argon2id tidak valid")
+		}
+		bits := 32
+		if k == "p" {
+			bits = 8
+		}
+		var n uint64
+		if k == "m" || k == "t" || k == "p" {
+			var err error
+			if n, err = strconv.ParseUint(v, 10, bits); err != nil {
+				return nil, errors.New("parameter argon2id tidak valid")
+			}
+		}
+		switch k {
+		case "m":
+			p.memory = uint32(n)
+		case "t":
+			p.iterations = uint32(n)
+		case "p":
+			p.parallelism = uint8(n)
+		case "k":
+			p.pepperID = v
+		}
+	}
+	if p.memory == 0 || p.iterations == 0 || p.parallelism == 0 {
+		return nil, errors.New("parameter argon2id tidak lengkap")
+	}
+
+	var err error
+	if p.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, err
+	}
+	if p.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// BcryptHasher mendukung hash bcrypt lama (dan bisa dipakai sebagai algoritma utama lewat PASSWORD_HASH_ALG=bcrypt)
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h *BcryptHasher) Supports(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	return string(bytes), err
+}
+
+func (h *BcryptHasher) Verify(password, encoded string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (h *BcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != h.Cost
+}
+
+type passwordHasherSet struct {
+	active PasswordHasher
+	all    []PasswordHasher
+}
+
+var (
+	hashersMu sync.Mutex
+	hashers   *passwordHasherSet
+)
+
+// LoadPasswordHashers membaca dan memvalidasi konfigurasi hashing password sekali saat startup.
+// Nilai yang tidak valid (bukan angka, di luar batas tipe, atau format pepper salah) dikembalikan
+// sebagai error supaya aplikasi berhenti, bukan diam-diam terpotong.
+func LoadPasswordHashers() error {
+	set, err := loadPasswordHashers()
+	if err != nil {
+		return err
+	}
+	hashersMu.Lock()
+	hashers = set
+	hashersMu.Unlock()
+	return nil
+}
+
+// passwordHashers mengembalikan hasher aktif (dipakai untuk hash baru) dan semua hasher yang dikenali
+func passwordHashers() (PasswordHasher, []PasswordHasher, error) {
+	hashersMu.Lock()
+	defer hashersMu.Unlock()
+	if hashers == nil {
+		set, err := loadPasswordHashers()
+		if err != nil {
+			return nil, nil, err
+		}
+		hashers = set
+	}
+	return hashers.active, hashers.all, nil
 }
 
+// envUint membaca bilangan bulat positif yang muat di `bits` bit (default jika tidak diisi)
+func envUint(key string, defaultValue uint64, bits int) (uint64, error) {
+	value, exists := os.LookupEnv(key)
+	if !exists || value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.ParseUint(value, 10, bits)
+	if err != nil || n == 0 {
+		return 0, fmt.Errorf("%s harus bilangan bulat 1 sampai %d", key, uint64(1)<<bits-1)
+	}
+	return n, nil
+}
+
+func loadPasswordHashers() (*passwordHasherSet, error) {
+	memory, err := envUint("ARGON2_MEMORY", 64*1024, 32)
+	if err != nil {
+		return nil, err
+	}
+	iterations, err := envUint("ARGON2_ITERATIONS", 3, 32)
+	if err != nil {
+		return nil, err
+	}
+	parallelism, err := envUint("ARGON2_PARALLELISM", 2, 8)
+	if err != nil {
+		return nil, err
+	}
+	saltLength, err := envUint("ARGON2_SALT_LENGTH", 16, 32)
+	if err != nil {
+		return nil, err
+	}
+	keyLength, err := envUint("ARGON2_KEY_LENGTH", 32, 32)
+	if err != nil {
+		return nil, err
+	}
+	if memory < 8*parallelism {
+		return nil, errors.New("ARGON2_MEMORY minimal 8 x ARGON2_PARALLELISM KiB")
+	}
+	if saltLength < 8 || keyLength < 16 {
+		return nil, errors.New("ARGON2_SALT_LENGTH minimal 8 dan ARGON2_KEY_LENGTH minimal 16 byte")
+	}
+
+	pepperID := GetEnv("PASSWORD_PEPPER_ID", "1")
+	if strings.ContainsAny(pepperID, "$,=:") {
+		return nil, errors.New("PASSWORD_PEPPER_ID tidak boleh mengandung $ , = atau :")
+	}
+	oldPeppers := map[string][]byte{}
+	for _, entry := range strings.Split(GetEnv("PASSWORD_OLD_PEPPERS", ""), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, secret, ok := strings.Cut(entry, ":")
+		if !ok || id == "" || secret == "" || strings.ContainsAny(id, "$=") {
+			return nil, errors.New("PASSWORD_OLD_PEPPERS harus berformat id:secret,id:secret")
+		}
+		if id == pepperID {
+			return nil, fmt.Errorf("pepper %s sudah aktif, jangan dicantumkan di PASSWORD_OLD_PEPPERS", id)
+		}
+		oldPeppers[id] = []byte(secret)
+	}
+
+	cost := bcrypt.DefaultCost
+	if value := GetEnv("BCRYPT_COST", ""); value != "" {
+		cost, err = strconv.Atoi(value)
+		if err != nil || cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("BCRYPT_COST harus %d sampai %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	}
+
+	argon := &Argon2idHasher{
+		Memory:      uint32(memory),
+		Iterations:  uint32(iterations),
+		Parallelism: uint8(parallelism),
+		SaltLength:  uint32(saltLength),
+		KeyLength:   uint32(keyLength),
+		PepperID:    pepperID,
+		Pepper:      []byte(GetEnv("PASSWORD_PEPPER", "")),
+		OldPeppers:  oldPeppers,
+	}
+	bc := &BcryptHasher{Cost: cost}
+
+	set := &passwordHasherSet{active: argon, all: []PasswordHasher{argon, bc}}
+	switch alg := GetEnv("PASSWORD_HASH_ALG", "argon2id"); alg {
+	case "argon2id":
+	case "bcrypt":
+		set.active = bc
+	default:
+		return nil, fmt.Errorf("PASSWORD_HASH_ALG tidak dikenal: %s", alg)
+	}
+	return set, nil
+}
+
+// HashPassword membuat hash password dengan algoritma dan parameter yang sedang aktif
 func HashPassword(password string) (string, error) {
-    bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-    return string(bytes), err
-}
\ No newline at end of file
+	active, _, err := passwordHashers()
+	if err != nil {
+		return "", err
+	}
+	return active.Hash(password)
+}
+
+// CheckPassword memverifikasi password terhadap hash Argon2id maupun bcrypt
+func CheckPassword(password, hash string) bool {
+	_, all, err := passwordHashers()
+	if err != nil {
+		return false
+	}
+	for _, h := range all {
+		if h.Supports(hash) {
+			ok, err := h.Verify(password, hash)
+			return err == nil && ok
+		}
+	}
+	return false
+}
+
+// PasswordNeedsRehash bernilai true jika hash dibuat dengan algoritma atau parameter yang bukan setelan saat ini
+func PasswordNeedsRehash(hash string) bool {
+	active, _, err := passwordHashers()
+	if err != nil {
+		return false
+	}
+	if !active.Supports(hash) {
+		return true
+	}
+	return active.NeedsRehash(hash)
+}
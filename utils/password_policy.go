@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"bufio"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Kebijakan password dibaca dari environment:
+//
+//	PASSWORD_MIN_LENGTH       default 8
+//	PASSWORD_MAX_LENGTH       default 128 (72 jika PASSWORD_HASH_ALG=bcrypt karena bcrypt memotong input)
+//	PASSWORD_REQUIRE_UPPER    default true
+//	PASSWORD_REQUIRE_LOWER    default true
+//	PASSWORD_REQUIRE_NUMBER   default true
+//	PASSWORD_REQUIRE_SYMBOL   default false
+//	PASSWORD_BLOCKLIST_FILE   file daftar password umum/bocor, satu per baris (default config/password_blocklist.txt)
+//	PASSWORD_HISTORY          jumlah password terakhir yang tidak boleh dipakai ulang, 0 untuk menonaktifkan (default 5)
+
+// PasswordViolation adalah satu aturan kebijakan password yang tidak terpenuhi
+type PasswordViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type PasswordPolicy struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireNumber bool
+	RequireSymbol bool
+	HistorySize   int
+	BlocklistFile string
+}
+
+var (
+	blocklistMu    sync.Mutex
+	blocklistFile  string
+	blocklistWords map[string]struct{}
+)
+
+// GetPasswordPolicy membaca kebijakan password dari environment
+func GetPasswordPolicy() *PasswordPolicy {
+	maxLength := 128
+	if GetEnv("PASSWORD_HASH_ALG", "argon2id") == "bcrypt" {
+		maxLength = 72
+	}
+	history := GetEnvInt("PASSWORD_HISTORY", 5)
+	if GetEnv("PASSWORD_HISTORY", "") == "0" {
+		history = 0
+	}
+	return &PasswordPolicy{
+		MinLength:     GetEnvInt("PASSWORD_MIN_LENGTH", 8),
+		MaxLength:     GetEnvInt("PASSWORD_MAX_LENGTH", maxLength),
+		RequireUpper:  GetEnv("PASSWORD_REQUIRE_UPPER", "true") == "true",
+		RequireLower:  GetEnv("PASSWORD_REQUIRE_LOWER", "true") == "true",
+		RequireNumber: GetEnv("PASSWORD_REQUIRE_NUMBER", "true") == "true",
+		RequireSymbol: GetEnv("PASSWORD_REQUIRE_SYMBOL", "false") == "true",
+		HistorySize:   history,
+		BlocklistFile: GetEnv("PASSWORD_BLOCKLIST_FILE", "config/password_blocklist.txt"),
+	}
+}
+
+// Validate mengembalikan semua aturan yang dilanggar password (kosong berarti valid).
+// previousHashes berisi hash password saat ini dan riwayatnya untuk mencegah pemakaian ulang.
+func (p *PasswordPolicy) Validate(password string, previousHashes ...string) []PasswordViolation {
+	var violations []PasswordViolation
+	add := func(rule, message string) {
+		violations = append(violations, PasswordViolation{Rule: rule, Message: message})
+	}
+
+	length := len([]rune(password))
+	if length < p.MinLength {
+		add("min_length", "Password minimal "+strconv.Itoa(p.MinLength)+" karakter")
+	}
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		add("max_length", "Password maksimal "+strconv.Itoa(p.MaxLength)+" byte")
+	}
+
+	var hasUpper, hasLower, hasNumber, hasSymbol bool
+	for _, char := range password {
+		switch {
+		case unicode.IsUpper(char):
+			hasUpper = true
+		case unicode.IsLower(char):
+			hasLower = true
+		case unicode.IsDigit(char):
+			hasNumber = true
+		case unicode.IsPunct(char) || unicode.IsSymbol(char) || unicode.IsSpace(char):
+			hasSymbol = true
+		}
+	}
+	if p.RequireUpper && !hasUpper {
+		add("uppercase", "Password harus mengandung huruf besar")
+	}
+	if p.RequireLower && !hasLower {
+		add("lowercase", "Password harus mengandung huruf kecil")
+	}
+	if p.RequireNumber && !hasNumber {
+		add("number", "Password harus mengandung angka")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		add("symbol", "Password harus mengandung simbol")
+	}
+
+	if p.isBlocked(password) {
+		add("blocklist", "Password terlalu umum atau pernah bocor, gunakan password lain")
+	}
+
+	if p.HistorySize > 0 {
+		for _, hash := range previousHashes {
+			if hash != "" && CheckPassword(password, hash) {
+				add("history", "Password tidak boleh sama dengan "+strconv.Itoa(p.HistorySize)+" password terakhir")
+				break
+			}
+		}
+	}
+
+	return violations
+}
+
+// RecentPasswordHashes mengambil hash password saat ini beserta riwayatnya sesuai HistorySize
+func (p *PasswordPolicy) RecentPasswordHashes(current string, history []string) []string {
+	if p.HistorySize <= 0 {
+		return nil
+	}
+	hashes := []string{current}
+	keep := p.HistorySize - 1
+	if keep > len(history) {
+		keep = len(history)
+	}
+	return append(hashes, history[len(history)-keep:]...)
+}
+
+func (p *PasswordPolicy) isBlocked(password string) bool {
+	words := loadPasswordBlocklist(p.BlocklistFile)
+	_, blocked := words[strings.ToLower(password)]
+	return blocked
+}
+
+// loadPasswordBlocklist memuat file blocklist sekali dan menyimpannya di memori
+func loadPasswordBlocklist(path string) map[string]struct{} {
+	blocklistMu.Lock()
+	defer blocklistMu.Unlock()
+
+	if blocklistWords != nil && blocklistFile == path {
+		return blocklistWords
+	}
+
+	words := map[string]struct{}{}
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			slog.Warn("Password blocklist tidak bisa dibaca", "error", err)
+		} else {
+			scanner := bufio.NewScanner(f)
+			for scanner.Scan() {
+				line := strings.TrimSpace(scanner.Text())
+				if line == "" || strings.HasPrefix(line, "#") {
+					continue
+				}
+				words[strings.ToLower(line)] = struct{}{}
+			}
+			f.Close()
+		}
+	}
+
+	blocklistFile = path
+	blocklistWords = words
+	return words
+}
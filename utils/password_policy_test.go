@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func testPasswordPolicy(t *testing.T) *PasswordPolicy {
+	t.Helper()
+	blocklist := filepath.Join(t.TempDir(), "blocklist.txt")
+	if err := os.WriteFile(blocklist, []byte("# password umum\nPassword123!\n\nqwerty\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return &PasswordPolicy{
+		MinLength:     8,
+		MaxLength:     16,
+		RequireUpper:  true,
+		RequireLower:  true,
+		RequireNumber: true,
+		RequireSymbol: true,
+		HistorySize:   3,
+		BlocklistFile: blocklist,
+	}
+}
+
+func violationRules(violations []PasswordViolation) []string {
+	rules := []string{}
+	for _, v := range violations {
+		rules = append(rules, v.Rule)
+	}
+	return rules
+}
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	policy := testPasswordPolicy(t)
+
+	tests := []struct {
+		name     string
+		password string
+		want     []string
+	}{
+		{name: "valid", password: "Sup3r-Aman", want: []string{}},
+		{name: "terlalu pendek", password: "Ab1-", want: []string{"min_length"}},
+		{name: "panjang dihitung per rune", password: "Äb1-äöüß", want: []string{}},
+		{name: "terlalu panjang dalam byte", password: "Ab1-ÄÄÄÄÄÄÄÄÄ", want: []string{"max_length"}},
+		{name: "tanpa huruf besar", password: "sup3r-aman", want: []string{"uppercase"}},
+		{name: "tanpa huruf kecil", password: "SUP3R-AMAN", want: []string{"lowercase"}},
+		{name: "tanpa angka", password: "Super-Aman", want: []string{"number"}},
+		{name: "tanpa simbol", password: "Sup3rAman", want: []string{"symbol"}},
+		{name: "spasi dihitung simbol", password: "Sup3r Aman", want: []string{}},
+		{name: "blocklist tidak peka huruf besar", password: "PASSWORD123!", want: []string{"lowercase", "blocklist"}},
+		{name: "banyak pelanggaran sekaligus", password: "abc", want: []string{"min_length", "uppercase", "number", "symbol"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := violationRules(policy.Validate(tt.password)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate(%q) = %v, want %v", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyValidateOptionalRules(t *testing.T) {
+	policy := &PasswordPolicy{MinLength: 4}
+	if got := policy.Validate("abcd"); len(got) != 0 {
+		t.Fatalf("aturan yang tidak diwajibkan tidak boleh dicek: %v", violationRules(got))
+	}
+}
+
+func TestPasswordPolicyValidateHistory(t *testing.T) {
+	policy := testPasswordPolicy(t)
+
+	oldHash, err := HashPassword("Lama-Pass1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	currentHash, err := HashPassword("Kini-Pass1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := []string{currentHash, oldHash}
+
+	tests := []struct {
+		name        string
+		password    string
+		historySize int
+		want        []string
+	}{
+		{name: "sama dengan password saat ini", password: "Kini-Pass1", historySize: 3, want: []string{"history"}},
+		{name: "sama dengan riwayat", password: "Lama-Pass1", historySize: 3, want: []string{"history"}},
+		{name: "password baru", password: "Baru-Pass1", historySize: 3, want: []string{}},
+		{name: "riwayat dinonaktifkan", password: "Lama-Pass1", historySize: 0, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := *policy
+			p.HistorySize = tt.historySize
+			if got := violationRules(p.Validate(tt.password, previous...)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate(%q) = %v, want %v", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecentPasswordHashes(t *testing.T) {
+	history := []string{"h1", "h2", "h3", "h4"}
+
+	tests := []struct {
+		name        string
+		historySize int
+		history     []string
+		want        []string
+	}{
+		{name: "dinonaktifkan", historySize: 0, history: history, want: nil},
+		{name: "hanya password saat ini", historySize: 1, history: history, want: []string{"current"}},
+		{name: "ambil riwayat terbaru", historySize: 3, history: history, want: []string{"current", "h3", "h4"}},
+		{name: "riwayat lebih sedikit dari batas", historySize: 5, history: []string{"h1"}, want: []string{"current", "h1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &PasswordPolicy{HistorySize: tt.historySize}
+			if got := p.RecentPasswordHashes("current", tt.history); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RecentPasswordHashes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// withPasswordEnv mengganti konfigurasi hashing untuk satu test. Hasher yang di-cache
+// dibuang setelah test (sebelum env dikembalikan) supaya test lain memuat ulang setelan default.
+func withPasswordEnv(t *testing.T, env map[string]string) error {
+	t.Helper()
+	t.Cleanup(func() {
+		hashersMu.Lock()
+		hashers = nil
+		hashersMu.Unlock()
+	})
+	// Parameter kecil supaya test cepat
+	t.Setenv("ARGON2_MEMORY", "64")
+	t.Setenv("ARGON2_ITERATIONS", "1")
+	t.Setenv("ARGON2_PARALLELISM", "1")
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+	return LoadPasswordHashers()
+}
+
+func TestParseArgon2id(t *testing.T) {
+	const salt, key = "c2FsdHNhbHRzYWx0c2FsdA", "a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"
+
+	tests := []struct {
+		name    string
+		encoded string
+		want    *argon2Params
+		wantErr bool
+	}{
+		{
+			name:    "valid",
+			encoded: "$argon2id$v=19$m=65536,t=3,p=2$" + salt + "$" + key,
+			want:    &argon2Params{memory: 65536, iterations: 3, parallelism: 2},
+		},
+		{
+			name:    "dengan pepper id",
+			encoded: "$argon2id$v=19$m=64,t=1,p=1,k=2025$" + salt + "$" + key,
+			want:    &argon2Params{memory: 64, iterations: 1, parallelism: 1, pepperID: "2025"},
+		},
+		{name: "bukan argon2id", encoded: "$argon2i$v=19$m=64,t=1,p=1$" + salt + "$" + key, wantErr: true},
+		{name: "versi lain", encoded: "$argon2id$v=16$m=64,t=1,p=1$" + salt + "$" + key, wantErr: true},
+		{name: "bagian kurang", encoded: "$argon2id$v=19$m=64,t=1,p=1$" + salt, wantErr: true},
+		{name: "parameter tanpa nilai", encoded: "$argon2id$v=19$m,t=1,p=1$" + salt + "$" + key, wantErr: true},
+		{name: "parameter bukan angka", encoded: "$argon2id$v=19$m=abc,t=1,p=1$" + salt + "$" + key, wantErr: true},
+		{name: "parameter tidak lengkap", encoded: "$argon2id$v=19$m=64,t=1$" + salt + "$" + key, wantErr: true},
+		{name: "parallelism melebihi uint8", encoded: "$argon2id$v=19$m=64,t=1,p=257$" + salt + "$" + key, wantErr: true},
+		{name: "memory melebihi uint32", encoded: "$argon2id$v=19$m=4294967297,t=1,p=1$" + salt + "$" + key, wantErr: true},
+		{name: "salt bukan base64", encoded: "$argon2id$v=19$m=64,t=1,p=1$!!!$" + key, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseArgon2id(tt.encoded)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseArgon2id seharusnya gagal, dapat %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseArgon2id: %v", err)
+			}
+			if got.memory != tt.want.memory || got.iterations != tt.want.iterations ||
+				got.parallelism != tt.want.parallelism || got.pepperID != tt.want.pepperID {
+				t.Errorf("parseArgon2id = %+v, want %+v", got, tt.want)
+			}
+			if len(got.salt) != 16 || len(got.key) != 29 {
+				t.Errorf("panjang salt/key = %d/%d", len(got.salt), len(got.key))
+			}
+		})
+	}
+}
+
+func TestLoadPasswordHashersRejectsInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+	}{
+		{name: "parallelism melebihi uint8", env: map[string]string{"ARGON2_PARALLELISM": "256"}},
+		{name: "memory melebihi uint32", env: map[string]string{"ARGON2_MEMORY": "4294967296"}},
+		{name: "iterations nol", env: map[string]string{"ARGON2_ITERATIONS": "0"}},
+		{name: "iterations negatif", env: map[string]string{"ARGON2_ITERATIONS": "-1"}},
+		{name: "bukan angka", env: map[string]string{"ARGON2_KEY_LENGTH": "32b"}},
+		{name: "memory terlalu kecil untuk parallelism", env: map[string]string{"ARGON2_MEMORY": "16", "ARGON2_PARALLELISM": "4"}},
+		{name: "salt terlalu pendek", env: map[string]string{"ARGON2_SALT_LENGTH": "4"}},
+		{name: "key terlalu pendek", env: map[string]string{"ARGON2_KEY_LENGTH": "8"}},
+		{name: "bcrypt cost di luar batas", env: map[string]string{"BCRYPT_COST": "40"}},
+		{name: "algoritma tidak dikenal", env: map[string]string{"PASSWORD_HASH_ALG": "md5"}},
+		{name: "pepper id mengandung $", env: map[string]string{"PASSWORD_PEPPER_ID": "a$b"}},
+		{name: "format pepper lama salah", env: map[string]string{"PASSWORD_OLD_PEPPERS": "1secret"}},
+		{name: "pepper lama sama dengan aktif", env: map[string]string{"PASSWORD_PEPPER_ID": "2", "PASSWORD_OLD_PEPPERS": "2:secret"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := withPasswordEnv(t, tt.env); err == nil {
+				t.Fatal("konfigurasi tidak valid seharusnya ditolak")
+			}
+		})
+	}
+}
+
+func TestHashAndCheckPassword(t *testing.T) {
+	if err := withPasswordEnv(t, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := HashPassword("Rahasia-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Fatalf("format hash tidak sesuai: %s", hash)
+	}
+	if !CheckPassword("Rahasia-123", hash) {
+		t.Error("password benar ditolak")
+	}
+	if CheckPassword("rahasia-123", hash) {
+		t.Error("password salah diterima")
+	}
+	if CheckPassword("Rahasia-123", "bukan-hash") {
+		t.Error("hash dengan format tidak dikenal diterima")
+	}
+}
+
+func TestPasswordPepperRotation(t *testing.T) {
+	if err := withPasswordEnv(t, map[string]string{"PASSWORD_PEPPER": "pepper-lama", "PASSWORD_PEPPER_ID": "1"}); err != nil {
+		t.Fatal(err)
+	}
+	oldHash, err := HashPassword("Rahasia-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(oldHash, ",k=1$") {
+		t.Fatalf("pepper id tidak dicatat di hash: %s", oldHash)
+	}
+
+	tests := []struct {
+		name       string
+		env        map[string]string
+		wantCheck  bool
+		wantRehash bool
+	}{
+		{
+			name:       "pepper lama masih aktif",
+			env:        map[string]string{"PASSWORD_PEPPER": "pepper-lama", "PASSWORD_PEPPER_ID": "1"},
+			wantCheck:  true,
+			wantRehash: false,
+		},
+		{
+			name:       "pepper baru, pepper lama di PASSWORD_OLD_PEPPERS",
+			env:        map[string]string{"PASSWORD_PEPPER": "pepper-baru", "PASSWORD_PEPPER_ID": "2", "PASSWORD_OLD_PEPPERS": "1:pepper-lama"},
+			wantCheck:  true,
+			wantRehash: true,
+		},
+		{
+			name:       "pepper lama sudah dihapus",
+			env:        map[string]string{"PASSWORD_PEPPER": "pepper-baru", "PASSWORD_PEPPER_ID": "2"},
+			wantCheck:  false,
+			wantRehash: true,
+		},
+		{
+			name:       "isi pepper lama salah",
+			env:        map[string]string{"PASSWORD_PEPPER": "pepper-baru", "PASSWORD_PEPPER_ID": "2", "PASSWORD_OLD_PEPPERS": "1:pepper-palsu"},
+			wantCheck:  false,
+			wantRehash: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := withPasswordEnv(t, tt.env); err != nil {
+				t.Fatal(err)
+			}
+			if got := CheckPassword("Rahasia-123", oldHash); got != tt.wantCheck {
+				t.Errorf("CheckPassword = %v, want %v", got, tt.wantCheck)
+			}
+			if got := PasswordNeedsRehash(oldHash); got != tt.wantRehash {
+				t.Errorf("PasswordNeedsRehash = %v, want %v", got, tt.wantRehash)
+			}
+		})
+	}
+}
+
+func TestPasswordNeedsRehash(t *testing.T) {
+	if err := withPasswordEnv(t, nil); err != nil {
+		t.Fatal(err)
+	}
+	current, err := HashPassword("Rahasia-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("Rahasia-123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Hash lama dengan parameter berbeda dibuat lewat hasher terpisah
+	weaker, err := (&Argon2idHasher{Memory: 32, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}).Hash("Rahasia-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	shortKey, err := (&Argon2idHasher{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 16}).Hash("Rahasia-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		hash string
+		want bool
+	}{
+		{name: "parameter saat ini", hash: current, want: false},
+		{name: "memory berbeda", hash: weaker, want: true},
+		{name: "panjang key berbeda", hash: shortKey, want: true},
+		{name: "bcrypt lama", hash: string(bcryptHash), want: true},
+		{name: "format tidak dikenal", hash: "bukan-hash", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PasswordNeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("PasswordNeedsRehash = %v, want %v", got, tt.want)
+			}
+			if tt.name == "bcrypt lama" && !CheckPassword("Rahasia-123", tt.hash) {
+				t.Error("hash bcrypt lama harus tetap bisa diverifikasi")
+			}
+		})
+	}
+}
+
+func TestPasswordNeedsRehashBcryptActive(t *testing.T) {
+	if err := withPasswordEnv(t, map[string]string{"PASSWORD_HASH_ALG": "bcrypt", "BCRYPT_COST": "5"}); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := HashPassword("Rahasia-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if PasswordNeedsRehash(hash) {
+		t.Error("hash bcrypt dengan cost aktif tidak perlu di-rehash")
+	}
+
+	if err := withPasswordEnv(t, map[string]string{"PASSWORD_HASH_ALG": "bcrypt", "BCRYPT_COST": "6"}); err != nil {
+		t.Fatal(err)
+	}
+	if !PasswordNeedsRehash(hash) {
+		t.Error("hash bcrypt dengan cost lama harus di-rehash")
+	}
+}
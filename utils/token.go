@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// GenerateOpaqueToken membuat token acak (base64url, 32 byte) beserta hash SHA-256-nya.
+// Token mentah hanya dikirim ke client, yang disimpan di database cukup hash-nya.
+func GenerateOpaqueToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, HashOpaqueToken(token), nil
+}
+
+// HashOpaqueToken menghitung hash SHA-256 (hex) dari token opaque
+func HashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
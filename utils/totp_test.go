@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// Vektor uji RFC 6238 Appendix B untuk HMAC-SHA1 dengan secret ASCII "12345678901234567890".
+// RFC memakai 8 digit, kode 6 digit adalah 6 digit terakhirnya.
+func TestValidateTOTPRFC6238Vectors(t *testing.T) {
+	secret := base32NoPad.EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "94287082"},
+		{unix: 1111111109, code: "07081804"},
+		{unix: 1111111111, code: "14050471"},
+		{unix: 1234567890, code: "89005924"},
+		{unix: 2000000000, code: "69279037"},
+		{unix: 20000000000, code: "65353130"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			code := tt.code[len(tt.code)-totpDigits:]
+			step, ok := ValidateTOTP(secret, code, time.Unix(tt.unix, 0))
+			if !ok {
+				t.Fatalf("kode %s pada T=%d ditolak", code, tt.unix)
+			}
+			if want := tt.unix / totpPeriod; step != want {
+				t.Errorf("step = %d, want %d", step, want)
+			}
+		})
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := base32NoPad.DecodeString(secret)
+	now := time.Unix(1700000000, 0)
+	current := now.Unix() / totpPeriod
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "step saat ini", secret: secret, code: totpCode(key, current), wantStep: current, wantOK: true},
+		{name: "step sebelumnya masih diterima", secret: secret, code: totpCode(key, current-1), wantStep: current - 1, wantOK: true},
+		{name: "step berikutnya masih diterima", secret: secret, code: totpCode(key, current+1), wantStep: current + 1, wantOK: true},
+		{name: "di luar toleransi", secret: secret, code: totpCode(key, current-2)},
+		{name: "spasi di sekitar kode", secret: secret, code: " " + totpCode(key, current) + " ", wantStep: current, wantOK: true},
+		{name: "secret huruf kecil", secret: strings.ToLower(secret), code: totpCode(key, current), wantStep: current, wantOK: true},
+		{name: "panjang kode salah", secret: secret, code: "12345"},
+		{name: "secret tidak valid", secret: "bukan-base32!", code: "123456"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(tt.secret, tt.code, now)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("ValidateTOTP = (%d, %v), want (%d, %v)", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+// Kode yang sama dalam satu periode menghasilkan step yang sama, step inilah yang dicatat
+// pemanggil (ConsumeTOTPStep) untuk menolak pemakaian ulang
+func TestValidateTOTPSameCodeSameStep(t *testing.T) {
+	secret := base32NoPad.EncodeToString([]byte("12345678901234567890"))
+	first, ok := ValidateTOTP(secret, "287082", time.Unix(30, 0))
+	if !ok {
+		t.Fatal("kode pertama ditolak")
+	}
+	second, ok := ValidateTOTP(secret, "287082", time.Unix(59, 0))
+	if !ok || second != first {
+		t.Fatalf("step kode yang sama berbeda: %d dan %d", first, second)
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("format kode pemulihan salah: %q", code)
+		}
+		if got := NormalizeRecoveryCode(" " + strings.ToUpper(code[:5]) + " " + code[5:]); got != code {
+			t.Errorf("NormalizeRecoveryCode = %q, want %q", got, code)
+		}
+	}
+}
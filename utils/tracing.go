@@ -0,0 +1,223 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/v2/event"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Konfigurasi tracing OpenTelemetry:
+//
+//	OTEL_TRACES_EXPORTER         otlp, stdout, atau none (default none)
+//	OTEL_EXPORTER_OTLP_ENDPOINT  endpoint OTLP/HTTP collector (default http://localhost:4318),
+//	                             variabel OTEL_EXPORTER_OTLP_* lain ikut dibaca exporter
+//	OTEL_SERVICE_NAME            nama service di trace (default hello-fiber)
+//	OTEL_TRACES_SAMPLER_ARG      rasio sampling trace baru, 0 sampai 1 (default 1)
+//
+// Dengan exporter none span tetap dibuat (tidak dikirim) supaya trace ID selalu ada di log
+// dan response error. Konteks trace W3C (traceparent/tracestate) dan baggage dibaca dari
+// request masuk lalu diteruskan ke request keluar lewat NewTracingTransport.
+
+const tracerName = "hello-fiber"
+
+// Tracer mengembalikan tracer aplikasi dari tracer provider global
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// InitTracer memasang tracer provider dan propagator global. Fungsi yang dikembalikan
+// mengirim sisa span dan menutup exporter, panggil saat program berhenti.
+func InitTracer() (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	ratio, err := strconv.ParseFloat(GetEnv("OTEL_TRACES_SAMPLER_ARG", "1"), 64)
+	if err != nil || ratio < 0 || ratio > 1 {
+		return nil, errors.New("OTEL_TRACES_SAMPLER_ARG harus angka 0 sampai 1")
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(GetEnv("OTEL_SERVICE_NAME", tracerName)),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		// Ikuti keputusan sampling pemanggil jika request membawa traceparent
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	}
+
+	exporterName := strings.ToLower(GetEnv("OTEL_TRACES_EXPORTER", "none"))
+	switch exporterName {
+	case "otlp":
+		exporter, err := otlptracehttp.New(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	case "stdout":
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithSyncer(exporter))
+	case "none", "":
+	default:
+		return nil, fmt.Errorf("OTEL_TRACES_EXPORTER tidak dikenal: %s", exporterName)
+	}
+
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// TraceID mengembalikan trace ID dari span di ctx, kosong jika tidak ada
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// MongoTracingMonitor membuat span client untuk setiap command MongoDB. Span menjadi anak
+// dari span di context operasi, jadi repository harus meneruskan context request.
+// Isi command tidak dicatat karena bisa berisi data pribadi atau hash password.
+func MongoTracingMonitor() *event.CommandMonitor {
+	var spans sync.Map
+
+	spanKey := func(connectionID string, requestID int64) string {
+		return connectionID + "/" + strconv.FormatInt(requestID, 10)
+	}
+	endSpan := func(connectionID string, requestID int64, failure error) {
+		v, ok := spans.LoadAndDelete(spanKey(connectionID, requestID))
+		if !ok {
+			return
+		}
+		span := v.(trace.Span)
+		if failure != nil {
+			span.RecordError(failure)
+			span.SetStatus(codes.Error, failure.Error())
+		}
+		span.End()
+	}
+
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			name := e.CommandName
+			attrs := []attribute.KeyValue{
+				semconv.DBSystemNameMongoDB,
+				semconv.DBNamespace(e.DatabaseName),
+				semconv.DBOperationName(e.CommandName),
+			}
+			// Elemen pertama command berisi nama collection untuk find, insert, update, dll.
+			if first, err := e.Command.IndexErr(0); err == nil && first.Key() == e.CommandName {
+				if collection, ok := first.Value().StringValueOK(); ok {
+					name += " " + collection
+					attrs = append(attrs, semconv.DBCollectionName(collection))
+				}
+			}
+
+			_, span := Tracer().Start(ctx, name,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(attrs...),
+			)
+			spans.Store(spanKey(e.ConnectionID, e.RequestID), span)
+		},
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			endSpan(e.ConnectionID, e.RequestID, nil)
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			endSpan(e.ConnectionID, e.RequestID, e.Failure)
+		},
+	}
+}
+
+// CombineCommandMonitors menggabungkan beberapa command monitor karena driver hanya menerima satu
+func CombineCommandMonitors(monitors ...*event.CommandMonitor) *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			for _, m := range monitors {
+				if m.Started != nil {
+					m.Started(ctx, e)
+				}
+			}
+		},
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			for _, m := range monitors {
+				if m.Succeeded != nil {
+					m.Succeeded(ctx, e)
+				}
+			}
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			for _, m := range monitors {
+				if m.Failed != nil {
+					m.Failed(ctx, e)
+				}
+			}
+		},
+	}
+}
+
+type tracingTransport struct {
+	base http.RoundTripper
+}
+
+// NewTracingTransport membungkus base (default http.DefaultTransport) supaya setiap request
+// keluar punya span client dan membawa header traceparent dari context request.
+func NewTracingTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &tracingTransport{base: base}
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := Tracer().Start(req.Context(), req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.ServerAddress(req.URL.Hostname()),
+			// Query string tidak dicatat karena bisa berisi code atau token
+			semconv.URLFull(req.URL.Scheme+"://"+req.URL.Host+req.URL.Path),
+		),
+	)
+	defer span.End()
+
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+	return resp, nil
+}